@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var (
+	// anchors lock stops, by index, to exact colors
+	anchors = map[int]color.RGBA{}
+	// anchorDE is the mean ΔE2000 between the anchors and the solved stops
+	anchorDE float64
+
+	anchorCoarse = 64  // grid spacing of the solver's pass over the whole parameter space
+	anchorGood   = 0.5 // ΔE2000 fit at which the current parameters are kept as they are
+	anchorMargin = 2.0 // ΔE2000 a distant fit must improve by to move the palette there
+)
+
+// lockHex parses "<stop> [hex]" and locks that stop, to its current color when no hex is given
+func lockHex(s string) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return
+	}
+	i, err := strconv.Atoi(fields[0])
+	if err != nil || i < 0 || i >= stopmax {
+		log.Println(fmt.Errorf("invalid stop %q", fields[0]))
+		return
+	}
+	c := color.RGBA{stoplist[i].r, stoplist[i].g, stoplist[i].b, 255}
+	if len(fields) > 1 {
+		if c, err = parseHex(fields[1]); err != nil {
+			log.Println(err)
+			return
+		}
+	}
+	anchors[i] = c
+	if i >= stops {
+		stops = i + 1
+	}
+	solveAnchors()
+}
+
+// unlockAll removes every anchor
+func unlockAll() {
+	anchors = map[int]color.RGBA{}
+	anchorDE = 0
+}
+
+// anchorFit calculates the mean ΔE2000 between the anchors and the stops generated from the parameters
+func anchorFit(p, d, y int, targets map[int]labColor, last int) float64 {
+	var prev, cur colorStop
+	total := 0.0
+	for i := 0; i <= last; i++ {
+		switch i {
+		case 0:
+			cur.setVal(float64(p))
+		case 1:
+			prev, cur = cur, colorStop{}
+			cur.setVal(float64(p + d))
+		default:
+			next := nextStop(prev.val, cur.val, ratio)
+			prev, cur = cur, colorStop{}
+			cur.setVal(next)
+		}
+		if t, ok := targets[i]; ok {
+			x := int(math.Round(cur.off))
+			if x >= pickerW {
+				x = pickerW - 1
+			}
+			total += deltaE2000(t, pickerLabAt(x, y))
+		}
+	}
+	return total / float64(len(targets))
+}
+
+// solveAnchors searches primary, distance and brightness for the stops closest to the anchors,
+// starting from the current parameters so anchors that already fit don't move the palette
+func solveAnchors() {
+	if len(anchors) == 0 {
+		return
+	}
+	targets := make(map[int]labColor, len(anchors))
+	last := 0
+	for i, c := range anchors {
+		targets[i] = rgbToLab(c)
+		if i > last {
+			last = i
+		}
+	}
+	bp, bd, by := primary, distance, brightness
+	best := anchorFit(bp, bd, by, targets, last)
+	if best > anchorGood {
+		// refine around the current parameters first
+		bp, bd, by, best = refineAnchors(bp, bd, by, best, targets, last, anchorCoarse)
+		// a sparse pass over the whole parameter space only wins when it is clearly better
+		fp, fd, fy, far := primary, distance, brightness, math.Inf(1)
+		for y := anchorCoarse / 2; y < pickerH; y += anchorCoarse {
+			for p := 0; p < screenW; p += anchorCoarse {
+				for d := -screenW; d <= screenW; d += anchorCoarse {
+					if fit := anchorFit(p, d, y, targets, last); fit < far {
+						far, fp, fd, fy = fit, p, d, y
+					}
+				}
+			}
+		}
+		if far+anchorMargin < best {
+			fp, fd, fy, far = refineAnchors(fp, fd, fy, far, targets, last, anchorCoarse/2)
+			if far+anchorMargin < best {
+				bp, bd, by, best = fp, fd, fy, far
+			}
+		}
+	}
+	importList = nil
+	primary, distance, brightness = bp, bd, by
+	anchorDE = best
+	clampParams()
+}
+
+// refineAnchors improves a fit by stepping each parameter, halving the step down to one pixel
+func refineAnchors(bp, bd, by int, best float64, targets map[int]labColor, last, start int) (int, int, int, float64) {
+	for s := start; s > 0; s /= 2 {
+		improved := true
+		for improved {
+			improved = false
+			for _, n := range [][3]int{{s, 0, 0}, {-s, 0, 0}, {0, s, 0}, {0, -s, 0}, {0, 0, s}, {0, 0, -s}} {
+				p, d, y := bp+n[0], bd+n[1], by+n[2]
+				if p < 0 || p >= screenW || d < -screenW || d > screenW || y < 0 || y >= pickerH {
+					continue
+				}
+				if fit := anchorFit(p, d, y, targets, last); fit < best {
+					best, bp, bd, by = fit, p, d, y
+					improved = true
+				}
+			}
+		}
+	}
+	return bp, bd, by, best
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"sort"
+	"strings"
+)
+
+// colormap arrangements of the stops
+const (
+	colormapOff        = iota
+	colormapSequential // darkest to lightest
+	colormapDiverging  // dark ends meeting at a midpoint stop, the lightest unless one is chosen
+	colormapCyclic     // generated order, wrapping back to the first stop
+	colormapTypes
+)
+
+var (
+	colormapType  = colormapOff
+	colormapNames = []string{"off", "sequential", "diverging", "cyclic"}
+	colormapN     = 256 // entries in exported colormaps
+	colormapMid   = -1  // stop diverging maps meet at, the lightest when not set
+
+	colormapStatusKey  string // palette and settings the status was worked out for
+	colormapStatusText string
+)
+
+// colormapMidpoint is the stop a diverging map meets at, the chosen midpoint or the lightest stop
+func colormapMidpoint(colors []color.RGBA) int {
+	if colormapMid >= 0 && colormapMid < len(colors) {
+		return colormapMid
+	}
+	mid := 0
+	for i, c := range colors {
+		if rgbToLab(c).l > rgbToLab(colors[mid]).l {
+			mid = i
+		}
+	}
+	return mid
+}
+
+// chooseMidpoint makes the selected stop the midpoint of diverging maps,
+// or goes back to the lightest stop when nothing is selected or it already is the midpoint
+func chooseMidpoint() {
+	if selected < 0 || selected == colormapMid {
+		colormapMid = -1
+		return
+	}
+	colormapMid = selected
+}
+
+// byLightness sorts colors from darkest to lightest
+func byLightness(colors []color.RGBA) {
+	sort.SliceStable(colors, func(i, j int) bool { return rgbToLab(colors[i]).l < rgbToLab(colors[j]).l })
+}
+
+// gradientColors arranges the stop colors for the current colormap type
+func gradientColors() []color.RGBA {
+	colors := paletteColors()
+	switch colormapType {
+	case colormapSequential:
+		byLightness(colors)
+	case colormapDiverging:
+		// the midpoint sits in the middle with the rest split between the two arms, darkest at the ends
+		m := colormapMidpoint(colors)
+		mid := colors[m]
+		rest := append(append([]color.RGBA{}, colors[:m]...), colors[m+1:]...)
+		byLightness(rest)
+		var left, right []color.RGBA
+		for i := len(rest) - 1; i >= 0; i-- {
+			if (len(rest)-1-i)%2 == 0 {
+				left = append([]color.RGBA{rest[i]}, left...)
+			} else {
+				right = append(right, rest[i])
+			}
+		}
+		colors = append(append(left, mid), right...)
+	case colormapCyclic:
+		colors = append(colors, colors[0])
+	}
+	return colors
+}
+
+// setColormapType selects the colormap type by name
+func setColormapType(name string) error {
+	for i, n := range colormapNames {
+		if n == name {
+			colormapType = i
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown colormap %q", name)
+}
+
+// colormapSamples interpolates n evenly spaced colors along the colormap
+func colormapSamples(n int) []color.RGBA {
+	samples := make([]color.RGBA, n)
+	colors := gradientColors()
+	for i := range samples {
+		samples[i] = gradientAt(colors, float64(i)/float64(n-1))
+	}
+	return samples
+}
+
+// colormapMonotonic checks the lightness only rises for sequential maps, and only rises
+// then falls for diverging maps, cyclic maps have no direction so always pass
+func colormapMonotonic() bool {
+	samples := colormapSamples(colormapN)
+	falling := false
+	prev := rgbToLab(samples[0]).l
+	for _, c := range samples[1:] {
+		l := rgbToLab(c).l
+		switch {
+		case colormapType == colormapSequential && l < prev:
+			return false
+		case colormapType == colormapDiverging && l < prev:
+			falling = true
+		case colormapType == colormapDiverging && l > prev && falling:
+			return false
+		}
+		prev = l
+	}
+	return true
+}
+
+// colormapStatus describes the colormap type and whether its lightness is monotonic,
+// only sampling the colormap again when the palette or settings have changed
+func colormapStatus() string {
+	if colormapType == colormapOff {
+		return colormapNames[colormapType]
+	}
+	key := fmt.Sprint(paletteKey(), colormapType, gradientSpace, colormapMid)
+	if key == colormapStatusKey {
+		return colormapStatusText
+	}
+	colormapStatusKey = key
+	colormapStatusText = colormapNames[colormapType]
+	if colormapType == colormapDiverging {
+		colormapStatusText += fmt.Sprintf(" at stop %d", colormapMidpoint(paletteColors())+1)
+	}
+	if colormapMonotonic() {
+		colormapStatusText += " (monotonic)"
+	} else {
+		colormapStatusText += " (not monotonic)"
+	}
+	return colormapStatusText
+}
+
+// exportMatplotlib generates a Python list of RGB floats for matplotlib's ListedColormap
+func exportMatplotlib() []byte {
+	var output strings.Builder
+	fmt.Fprintf(&output, "# %s, %s\n", paletteName(), colormapNames[colormapType])
+	output.WriteString("from matplotlib.colors import ListedColormap\n\ncmap_data = [\n")
+	for _, c := range colormapSamples(colormapN) {
+		fmt.Fprintf(&output, "    [%.6f, %.6f, %.6f],\n", float64(c.R)/255, float64(c.G)/255, float64(c.B)/255)
+	}
+	fmt.Fprintf(&output, "]\n\ncmap = ListedColormap(cmap_data, name=%q)\n", paletteFilename(""))
+	return []byte(output.String())
+}
+
+// vegaScheme is a color scheme that can be registered with vega.scheme
+type vegaScheme struct {
+	Name   string   `json:"name"`
+	Type   string   `json:"type"`
+	Colors []string `json:"colors"`
+}
+
+// exportVega generates a Vega scheme with the stops arranged as the colormap
+func exportVega() []byte {
+	scheme := vegaScheme{Name: paletteFilename(""), Type: colormapNames[colormapType]}
+	if colormapType == colormapOff {
+		scheme.Type = "categorical"
+	}
+	for _, c := range gradientColors() {
+		scheme.Colors = append(scheme.Colors, fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B))
+	}
+	data, err := json.MarshalIndent(scheme, "", "  ")
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
+
+// exportLUT generates a 256 entry lookup table of all reds, then greens, then blues
+func exportLUT() []byte {
+	samples := colormapSamples(256)
+	data := make([]byte, 768)
+	for i, c := range samples {
+		data[i], data[256+i], data[512+i] = c.R, c.G, c.B
+	}
+	return data
+}
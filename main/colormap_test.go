@@ -0,0 +1,68 @@
+package main
+
+import (
+	"image/color"
+	"testing"
+)
+
+// gray is an opaque gray of the given level
+func gray(v uint8) color.RGBA {
+	return color.RGBA{v, v, v, 255}
+}
+
+func TestColormapMonotonic(t *testing.T) {
+	defer func(typ, mid int) { colormapType, colormapMid = typ, mid }(colormapType, colormapMid)
+	grays := []color.RGBA{gray(200), gray(40), gray(120), gray(240), gray(80)}
+	tests := []struct {
+		name string
+		typ  int
+		mid  int
+		want bool
+	}{
+		{"sequential", colormapSequential, -1, true},
+		{"diverging at the lightest", colormapDiverging, -1, true},
+		{"diverging at a dark stop", colormapDiverging, 1, false},
+		{"cyclic", colormapCyclic, -1, true},
+	}
+	for _, test := range tests {
+		setTestStops(grays)
+		colormapType, colormapMid = test.typ, test.mid
+		if got := colormapMonotonic(); got != test.want {
+			t.Errorf("%s: monotonic %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestColormapMidpoint(t *testing.T) {
+	defer func(mid, sel int) { colormapMid, selected = mid, sel }(colormapMid, selected)
+	colors := []color.RGBA{gray(200), gray(40), gray(240), gray(80)}
+	tests := []struct {
+		name     string
+		mid, sel int
+		want     int
+	}{
+		{"lightest", -1, -1, 2},
+		{"chosen", 1, -1, 1},
+		{"selection is ignored", -1, 3, 2},
+		{"out of range", 7, -1, 2},
+	}
+	for _, test := range tests {
+		colormapMid, selected = test.mid, test.sel
+		if got := colormapMidpoint(colors); got != test.want {
+			t.Errorf("%s: midpoint %d, want %d", test.name, got, test.want)
+		}
+	}
+}
+
+func TestChooseMidpoint(t *testing.T) {
+	defer func(mid, sel int) { colormapMid, selected = mid, sel }(colormapMid, selected)
+	colormapMid, selected = -1, 2
+	chooseMidpoint()
+	if colormapMid != 2 {
+		t.Errorf("midpoint %d, want 2", colormapMid)
+	}
+	chooseMidpoint()
+	if colormapMid != -1 {
+		t.Errorf("choosing the midpoint again left %d, want the lightest", colormapMid)
+	}
+}
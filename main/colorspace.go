@@ -0,0 +1,280 @@
+package main
+
+import (
+	"image/color"
+	"math"
+)
+
+// rgbToHSL converts 8-bit RGB to hue (0-360), saturation and lightness (0-1)
+func rgbToHSL(r, g, b uint8) (h, s, l float64) {
+	rf, gf, bf := float64(r)/255, float64(g)/255, float64(b)/255
+	max := math.Max(rf, math.Max(gf, bf))
+	min := math.Min(rf, math.Min(gf, bf))
+	l = (max + min) / 2
+	if max == min {
+		return 0, 0, l
+	}
+	d := max - min
+	if l > 0.5 {
+		s = d / (2 - max - min)
+	} else {
+		s = d / (max + min)
+	}
+	h = hueFromRGB(rf, gf, bf, max, d)
+	return
+}
+
+// hslToRGB converts hue (0-360), saturation and lightness (0-1) to a color
+func hslToRGB(h, s, l float64) color.RGBA {
+	c := (1 - math.Abs(2*l-1)) * s
+	return chromaToRGB(h, c, l-c/2)
+}
+
+// hueFromRGB calculates the hue in degrees given the max channel and chroma
+func hueFromRGB(r, g, b, max, d float64) (h float64) {
+	switch max {
+	case r:
+		h = math.Mod((g-b)/d, 6)
+	case g:
+		h = (b-r)/d + 2
+	default:
+		h = (r-g)/d + 4
+	}
+	h *= 60
+	if h < 0 {
+		h += 360
+	}
+	return
+}
+
+// chromaToRGB builds a color from hue, chroma and the lightness match value
+func chromaToRGB(h, c, m float64) color.RGBA {
+	h = math.Mod(h, 360)
+	if h < 0 {
+		h += 360
+	}
+	x := c * (1 - math.Abs(math.Mod(h/60, 2)-1))
+	var r, g, b float64
+	switch {
+	case h < 60:
+		r, g, b = c, x, 0
+	case h < 120:
+		r, g, b = x, c, 0
+	case h < 180:
+		r, g, b = 0, c, x
+	case h < 240:
+		r, g, b = 0, x, c
+	case h < 300:
+		r, g, b = x, 0, c
+	default:
+		r, g, b = c, 0, x
+	}
+	return color.RGBA{unitToByte(r + m), unitToByte(g + m), unitToByte(b + m), 255}
+}
+
+// unitToByte clamps a 0-1 value and scales it to 0-255
+func unitToByte(v float64) uint8 {
+	return uint8(math.Round(math.Max(0, math.Min(1, v)) * 255))
+}
+
+// rgbToHSB converts 8-bit RGB to hue (0-360), saturation and brightness (0-1)
+func rgbToHSB(r, g, b uint8) (h, s, v float64) {
+	rf, gf, bf := float64(r)/255, float64(g)/255, float64(b)/255
+	max := math.Max(rf, math.Max(gf, bf))
+	min := math.Min(rf, math.Min(gf, bf))
+	v = max
+	if max == min {
+		return 0, 0, v
+	}
+	d := max - min
+	s = d / max
+	h = hueFromRGB(rf, gf, bf, max, d)
+	return
+}
+
+// hsbToRGB converts hue (0-360), saturation and brightness (0-1) to a color
+func hsbToRGB(h, s, v float64) color.RGBA {
+	c := v * s
+	return chromaToRGB(h, c, v-c)
+}
+
+// labColor is a CIE L*a*b* color using the D65 white point
+type labColor struct {
+	l, a, b float64
+}
+
+// srgbToLinear removes the sRGB transfer curve from an 8-bit channel
+func srgbToLinear(v uint8) float64 {
+	return unitToLinear(float64(v) / 255)
+}
+
+// unitToLinear removes the sRGB transfer curve from a 0-1 channel
+func unitToLinear(c float64) float64 {
+	if c <= 0.04045 {
+		return c / 12.92
+	}
+	return math.Pow((c+0.055)/1.055, 2.4)
+}
+
+// linearToSRGB applies the sRGB transfer curve to a linear channel
+func linearToSRGB(c float64) uint8 {
+	return unitToByte(linearToUnit(c))
+}
+
+// linearToUnit applies the sRGB transfer curve to a linear channel, clamped to 0-1
+func linearToUnit(c float64) float64 {
+	if c <= 0.0031308 {
+		c *= 12.92
+	} else {
+		c = 1.055*math.Pow(c, 1/2.4) - 0.055
+	}
+	return math.Max(0, math.Min(1, c))
+}
+
+// toRGBA converts any color to opaque 8-bit RGBA
+func toRGBA(c color.Color) color.RGBA {
+	r, g, b, _ := c.RGBA()
+	return color.RGBA{uint8(r >> 8), uint8(g >> 8), uint8(b >> 8), 255}
+}
+
+// rgbToLab converts an 8-bit RGB color to L*a*b*
+func rgbToLab(c color.RGBA) labColor {
+	return unitToLab(float64(c.R)/255, float64(c.G)/255, float64(c.B)/255)
+}
+
+// unitToLab converts 0-1 sRGB channels to L*a*b*
+func unitToLab(r, g, b float64) labColor {
+	r, g, b = unitToLinear(r), unitToLinear(g), unitToLinear(b)
+	x := (0.4124564*r + 0.3575761*g + 0.1804375*b) / 0.95047
+	y := 0.2126729*r + 0.7151522*g + 0.0721750*b
+	z := (0.0193339*r + 0.1191920*g + 0.9503041*b) / 1.08883
+	f := func(t float64) float64 {
+		if t > 216.0/24389 {
+			return math.Cbrt(t)
+		}
+		return (24389.0/27*t + 16) / 116
+	}
+	fx, fy, fz := f(x), f(y), f(z)
+	return labColor{116*fy - 16, 500 * (fx - fy), 200 * (fy - fz)}
+}
+
+// labToRGB converts an L*a*b* color back to 8-bit RGB
+func labToRGB(c labColor) color.RGBA {
+	u := labToUnit(c)
+	return color.RGBA{unitToByte(u[0]), unitToByte(u[1]), unitToByte(u[2]), 255}
+}
+
+// labToUnit converts an L*a*b* color to 0-1 sRGB channels
+func labToUnit(c labColor) [3]float64 {
+	fy := (c.l + 16) / 116
+	fx := fy + c.a/500
+	fz := fy - c.b/200
+	f := func(t float64) float64 {
+		if t3 := t * t * t; t3 > 216.0/24389 {
+			return t3
+		}
+		return (116*t - 16) / (24389.0 / 27)
+	}
+	x, y, z := f(fx)*0.95047, f(fy), f(fz)*1.08883
+	r := 3.2404542*x - 1.5371385*y - 0.4985314*z
+	g := -0.9692660*x + 1.8760108*y + 0.0415560*z
+	b := 0.0556434*x - 0.2040259*y + 1.0572252*z
+	return [3]float64{linearToUnit(r), linearToUnit(g), linearToUnit(b)}
+}
+
+// deltaE76 is the euclidean distance between two L*a*b* colors
+func deltaE76(p, q labColor) float64 {
+	return math.Sqrt((p.l-q.l)*(p.l-q.l) + (p.a-q.a)*(p.a-q.a) + (p.b-q.b)*(p.b-q.b))
+}
+
+// deltaE2000 is the CIEDE2000 perceptual distance between two L*a*b* colors
+func deltaE2000(p, q labColor) float64 {
+	const deg = math.Pi / 180
+	c1 := math.Hypot(p.a, p.b)
+	c2 := math.Hypot(q.a, q.b)
+	cm7 := math.Pow((c1+c2)/2, 7)
+	g := 0.5 * (1 - math.Sqrt(cm7/(cm7+math.Pow(25, 7))))
+	a1, a2 := p.a*(1+g), q.a*(1+g)
+	c1p, c2p := math.Hypot(a1, p.b), math.Hypot(a2, q.b)
+	hue := func(a, b float64) float64 {
+		if a == 0 && b == 0 {
+			return 0
+		}
+		h := math.Atan2(b, a) / deg
+		if h < 0 {
+			h += 360
+		}
+		return h
+	}
+	h1, h2 := hue(a1, p.b), hue(a2, q.b)
+
+	// differences in lightness, chroma and hue
+	dl := q.l - p.l
+	dc := c2p - c1p
+	var dh float64
+	if c1p*c2p != 0 {
+		dh = h2 - h1
+		if dh > 180 {
+			dh -= 360
+		} else if dh < -180 {
+			dh += 360
+		}
+	}
+	dH := 2 * math.Sqrt(c1p*c2p) * math.Sin(dh/2*deg)
+
+	// means used by the weighting functions
+	lm := (p.l + q.l) / 2
+	cm := (c1p + c2p) / 2
+	hm := h1 + h2
+	if c1p*c2p != 0 {
+		if math.Abs(h1-h2) <= 180 {
+			hm /= 2
+		} else if h1+h2 < 360 {
+			hm = (hm + 360) / 2
+		} else {
+			hm = (hm - 360) / 2
+		}
+	}
+	t := 1 - 0.17*math.Cos((hm-30)*deg) + 0.24*math.Cos(2*hm*deg) + 0.32*math.Cos((3*hm+6)*deg) - 0.20*math.Cos((4*hm-63)*deg)
+	dTheta := 30 * math.Exp(-((hm-275)/25)*((hm-275)/25))
+	cm7 = math.Pow(cm, 7)
+	rc := 2 * math.Sqrt(cm7/(cm7+math.Pow(25, 7)))
+	sl := 1 + 0.015*(lm-50)*(lm-50)/math.Sqrt(20+(lm-50)*(lm-50))
+	sc := 1 + 0.045*cm
+	sh := 1 + 0.015*cm*t
+	rt := -math.Sin(2*dTheta*deg) * rc
+
+	return math.Sqrt((dl/sl)*(dl/sl) + (dc/sc)*(dc/sc) + (dH/sh)*(dH/sh) + rt*(dc/sc)*(dH/sh))
+}
+
+// okLabColor is a color in the OKLab perceptual space
+type okLabColor struct {
+	l, a, b float64
+}
+
+// rgbToOKLab converts an 8-bit RGB color to OKLab
+func rgbToOKLab(c color.RGBA) okLabColor {
+	r, g, b := srgbToLinear(c.R), srgbToLinear(c.G), srgbToLinear(c.B)
+	l := math.Cbrt(0.4122214708*r + 0.5363325363*g + 0.0514459929*b)
+	m := math.Cbrt(0.2119034982*r + 0.6806995451*g + 0.1073969566*b)
+	s := math.Cbrt(0.0883024619*r + 0.2817188376*g + 0.6299787005*b)
+	return okLabColor{
+		0.2104542553*l + 0.7936177850*m - 0.0040720468*s,
+		1.9779984951*l - 2.4285922050*m + 0.4505937099*s,
+		0.0259040371*l + 0.7827717662*m - 0.8086757660*s,
+	}
+}
+
+// okLabToRGB converts an OKLab color back to 8-bit RGB
+func okLabToRGB(c okLabColor) color.RGBA {
+	l := c.l + 0.3963377774*c.a + 0.2158037573*c.b
+	m := c.l - 0.1055613458*c.a - 0.0638541728*c.b
+	s := c.l - 0.0894841775*c.a - 1.2914855480*c.b
+	l, m, s = l*l*l, m*m*m, s*s*s
+	return color.RGBA{
+		linearToSRGB(4.0767416621*l - 3.3077115913*m + 0.2309699292*s),
+		linearToSRGB(-1.2684380046*l + 2.6097574011*m - 0.3413193965*s),
+		linearToSRGB(-0.0041960863*l - 0.7034186147*m + 1.7076147010*s),
+		255,
+	}
+}
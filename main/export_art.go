@@ -0,0 +1,115 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"html"
+	"io"
+	"unicode/utf16"
+)
+
+// exportKPL generates a zipped Krita palette
+func exportKPL() []byte {
+	colorset := fmt.Sprintf("<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<Colorset version=\"1.0\" readonly=\"false\" columns=\"4\" name=\"%s\" comment=\"\">", html.EscapeString(paletteName()))
+	for i, s := range activeStops() {
+		colorset = fmt.Sprintf("%s\n <ColorSetEntry name=\"%s\" id=\"%d\" spot=\"false\" bitdepth=\"U8\">", colorset, html.EscapeString(stopLabel(i)), i)
+		colorset = fmt.Sprintf("%s\n  <RGB r=\"%.6f\" g=\"%.6f\" b=\"%.6f\" space=\"sRGB-elle-V2-srgbtrc.icc\"/>", colorset, float64(s.r)/255, float64(s.g)/255, float64(s.b)/255)
+		colorset = fmt.Sprintf("%s\n  <Position row=\"%d\" column=\"%d\"/>\n </ColorSetEntry>", colorset, i/4, i%4)
+	}
+	colorset += "\n</Colorset>\n"
+
+	return zipFiles([]zipFile{
+		// the mimetype must come first and be stored uncompressed
+		{name: "mimetype", data: []byte("krita/x-colorset"), store: true},
+		{name: "colorset.xml", data: []byte(colorset)},
+		{name: "profiles.xml", data: []byte("<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<Profiles/>\n")},
+	})
+}
+
+// exportPaintNET generates a Paint.NET palette of FFRRGGBB lines, each named by the comment before it
+func exportPaintNET() []byte {
+	output := fmt.Sprintf("; paint.net Palette File\n; Generated by %s\n; Colors: %d", windowTitle, stops)
+	for i, s := range activeStops() {
+		output = fmt.Sprintf("%s\n; %s\nFF%02X%02X%02X", output, stopLabel(i), s.r, s.g, s.b)
+	}
+	return []byte(output + "\n")
+}
+
+// exportSOC generates a LibreOffice color table
+func exportSOC() []byte {
+	output := "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<office:color-table xmlns:office=\"http://openoffice.org/2000/office\" xmlns:draw=\"http://openoffice.org/2000/drawing\">"
+	for i, s := range activeStops() {
+		output = fmt.Sprintf("%s\n  <draw:color draw:name=\"%s\" draw:color=\"#%02x%02x%02x\"/>", output, html.EscapeString(stopLabel(i)), s.r, s.g, s.b)
+	}
+	return []byte(output + "\n</office:color-table>\n")
+}
+
+// zipFile is a single entry in a zipped export
+type zipFile struct {
+	name  string
+	data  []byte
+	store bool // store without compression
+}
+
+// zipFiles archives the given files in order
+func zipFiles(files []zipFile) []byte {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for _, f := range files {
+		header := &zip.FileHeader{Name: f.name, Method: zip.Deflate}
+		if f.store {
+			header.Method = zip.Store
+		}
+		fw, err := w.CreateHeader(header)
+		if err != nil {
+			panic(err)
+		}
+		if _, err := io.Copy(fw, bytes.NewReader(f.data)); err != nil {
+			panic(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		panic(err)
+	}
+	return buf.Bytes()
+}
+
+// exportASE generates an Adobe Swatch Exchange file with the palette in one group
+func exportASE() []byte {
+	var buf bytes.Buffer
+	write := func(v interface{}) {
+		if err := binary.Write(&buf, binary.BigEndian, v); err != nil {
+			panic(err)
+		}
+	}
+	// names are null terminated UTF-16 prefixed by their length in characters
+	name := func(s string) []byte {
+		var b bytes.Buffer
+		u := append(utf16.Encode([]rune(s)), 0)
+		binary.Write(&b, binary.BigEndian, uint16(len(u)))
+		binary.Write(&b, binary.BigEndian, u)
+		return b.Bytes()
+	}
+	block := func(kind uint16, body []byte) {
+		write(kind)
+		write(uint32(len(body)))
+		write(body)
+	}
+
+	write([]byte("ASEF"))
+	write([]uint16{1, 0})
+	write(uint32(stops + 2))
+	block(aseGroupStart, name(paletteName()))
+	for i, s := range activeStops() {
+		body := bytes.NewBuffer(name(stopLabel(i)))
+		body.WriteString("RGB ")
+		binary.Write(body, binary.BigEndian, []float32{float32(s.r) / 255, float32(s.g) / 255, float32(s.b) / 255})
+		// global color type
+		binary.Write(body, binary.BigEndian, uint16(0))
+		block(aseColorEntry, body.Bytes())
+	}
+	block(aseGroupEnd, nil)
+	return buf.Bytes()
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"go/format"
+	"strings"
+)
+
+// goPackage is the package name declared by exported Go source
+var goPackage = "palette"
+
+// exportGo generates a gofmt-clean Go source file declaring the palette
+func exportGo() []byte {
+	var src strings.Builder
+	src.WriteString("// Code generated by PhiBar. DO NOT EDIT.\n")
+	fmt.Fprintf(&src, "// %s\n", paletteName())
+	fmt.Fprintf(&src, "// primary: %d, distance: %d, brightness: %d, step: %d, stops: %d\n\n", primary, distance, brightness, step, stops)
+	fmt.Fprintf(&src, "package %s\n\n", goPackage)
+	src.WriteString("import \"image/color\"\n\n")
+
+	// named values for each stop
+	src.WriteString("// Palette stops\nvar (\n")
+	names := make([]string, 0, stops)
+	for i, s := range activeStops() {
+		name := stopName(i)
+		names = append(names, name)
+		comment := s.hex()
+		if stopEdited(i) {
+			comment += ", edited"
+		}
+		fmt.Fprintf(&src, "%s = color.RGBA{R: 0x%02x, G: 0x%02x, B: 0x%02x, A: 0xff} // %s\n", name, s.r, s.g, s.b, comment)
+	}
+	src.WriteString(")\n\n")
+
+	// the ordered slice
+	src.WriteString("// Palette holds every stop in order\n")
+	fmt.Fprintf(&src, "var Palette = []color.RGBA{%s}\n", strings.Join(names, ", "))
+
+	output, err := format.Source([]byte(src.String()))
+	if err != nil {
+		// the generated source is always valid, but fall back to the raw text just in case
+		return []byte(src.String())
+	}
+	return output
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// colorsetNegative uses the negative color for the dark appearance instead of flipping lightness
+var colorsetNegative = false
+
+// colorsetColor is a single color entry in an asset catalog Contents.json
+type colorsetColor struct {
+	Appearances []colorsetAppearance `json:"appearances,omitempty"`
+	Color       colorsetValue        `json:"color"`
+	Idiom       string               `json:"idiom"`
+}
+
+// colorsetAppearance describes when a colorset entry applies
+type colorsetAppearance struct {
+	Appearance string `json:"appearance"`
+	Value      string `json:"value"`
+}
+
+// colorsetValue holds the color space and components of an entry
+type colorsetValue struct {
+	ColorSpace string            `json:"color-space"`
+	Components map[string]string `json:"components"`
+}
+
+// colorsetInfo is the info block every asset catalog Contents.json carries
+type colorsetInfo struct {
+	Author  string `json:"author"`
+	Version int    `json:"version"`
+}
+
+// colorsetContents is the full Contents.json document
+type colorsetContents struct {
+	Colors []colorsetColor `json:"colors,omitempty"`
+	Info   colorsetInfo    `json:"info"`
+}
+
+// exportAndroid generates an Android res/values/colors.xml resource file,
+// resource names must stay identifiers so edited stops are marked with a comment
+func exportAndroid() []byte {
+	output := "<?xml version=\"1.0\" encoding=\"utf-8\"?>\n<resources>"
+	for i, s := range activeStops() {
+		output = fmt.Sprintf("%s\n    <color name=\"%s\">#FF%02X%02X%02X</color>", output, strings.ToLower(stopName(i)), s.r, s.g, s.b)
+		if stopEdited(i) {
+			output += " <!-- edited -->"
+		}
+	}
+	return []byte(output + "\n</resources>\n")
+}
+
+// exportColorset writes an Xcode asset catalog with one colorset per stop into dir
+func exportColorset(dir string) error {
+	catalog := filepath.Join(dir, "PhiBar.xcassets")
+	if err := writeColorsetContents(catalog, colorsetContents{}); err != nil {
+		return err
+	}
+	for i, s := range activeStops() {
+		dark := s.dark()
+		contents := colorsetContents{
+			Colors: []colorsetColor{
+				{Color: newColorsetValue(s.color), Idiom: "universal"},
+				{
+					Appearances: []colorsetAppearance{{Appearance: "luminosity", Value: "dark"}},
+					Color:       newColorsetValue(dark),
+					Idiom:       "universal",
+				},
+			},
+		}
+		if err := writeColorsetContents(filepath.Join(catalog, stopName(i)+".colorset"), contents); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newColorsetValue converts a color to sRGB hex components
+func newColorsetValue(c color.Color) colorsetValue {
+	r, g, b, _ := c.RGBA()
+	return colorsetValue{
+		ColorSpace: "srgb",
+		Components: map[string]string{
+			"alpha": "1.000",
+			"red":   fmt.Sprintf("0x%02X", uint8(r>>8)),
+			"green": fmt.Sprintf("0x%02X", uint8(g>>8)),
+			"blue":  fmt.Sprintf("0x%02X", uint8(b>>8)),
+		},
+	}
+}
+
+// writeColorsetContents creates dir and writes its Contents.json
+func writeColorsetContents(dir string, contents colorsetContents) error {
+	contents.Info = colorsetInfo{Author: strings.ToLower(windowTitle), Version: 1}
+	data, err := json.MarshalIndent(contents, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, "Contents.json"), data, 0644)
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+var (
+	sheetShades = 0 // number of tint and shade swatches drawn on each side of a stop
+
+	sheetW       = 960
+	sheetHeaderH = 90
+	sheetBarY    = 48 // top of the gradient bar in the header
+	sheetBarH    = 30
+	sheetRowH    = 150
+	sheetSwatch  = 110
+	sheetLineH   = 24
+	sheetShadeW  = 30
+	sheetGap     = 5
+)
+
+// sheetText returns the labels printed next to the stop at index i
+func sheetText(i int, s colorStop) []string {
+	title := stopTitle(i)
+	if stopEdited(i) {
+		title += " (edited)"
+	}
+	return []string{title, s.hex(), s.rgb(), s.cmyk()}
+}
+
+// sheetParams describes the generation parameters for the sheet header
+func sheetParams() string {
+	return fmt.Sprintf("%s - primary: %d distance: %d brightness: %d stops: %d", paletteName(), primary, distance, brightness, stops)
+}
+
+// sheetH calculates the height of the sheet for the active stops
+func sheetH() int {
+	return sheetHeaderH + stops*sheetRowH
+}
+
+// mixColor blends c toward target by t (0-1)
+func mixColor(c, target color.RGBA, t float64) color.RGBA {
+	mix := func(a, b uint8) uint8 {
+		return uint8(float64(a) + (float64(b)-float64(a))*t + 0.5)
+	}
+	return color.RGBA{mix(c.R, target.R), mix(c.G, target.G), mix(c.B, target.B), 255}
+}
+
+// shadeRow generates the shades, the stop color itself, then the tints, from darkest to lightest
+func shadeRow(s colorStop) []color.RGBA {
+	base := color.RGBA{s.r, s.g, s.b, 255}
+	row := make([]color.RGBA, 0, sheetShades*2+1)
+	for i := sheetShades; i > 0; i-- {
+		row = append(row, mixColor(base, color.RGBA{0, 0, 0, 255}, float64(i)/float64(sheetShades+1)))
+	}
+	row = append(row, base)
+	for i := 1; i <= sheetShades; i++ {
+		row = append(row, mixColor(base, color.RGBA{255, 255, 255, 255}, float64(i)/float64(sheetShades+1)))
+	}
+	return row
+}
+
+// renderSheet draws the swatch sheet without needing a window
+func renderSheet() *image.RGBA {
+	sheet := image.NewRGBA(image.Rect(0, 0, sheetW, sheetH()))
+	draw.Draw(sheet, sheet.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+
+	drawer := &font.Drawer{Dst: sheet, Src: image.NewUniform(color.Black), Face: arcadeFont}
+	label := func(x, y int, s string) {
+		if drawer.Face == nil {
+			return
+		}
+		drawer.Dot = fixed.P(x, y)
+		drawer.DrawString(s)
+	}
+	label(padding, padding+int(fontSize), sheetParams())
+	bar := image.Rect(padding, sheetBarY, sheetW-padding, sheetBarY+sheetBarH)
+	draw.Draw(sheet, bar, renderGradient(bar.Dx(), bar.Dy()), image.Point{}, draw.Src)
+
+	for i, s := range activeStops() {
+		top := sheetHeaderH + i*sheetRowH
+		swatch := image.Rect(padding, top, padding+sheetSwatch, top+sheetSwatch)
+		draw.Draw(sheet, swatch, image.NewUniform(s.color), image.Point{}, draw.Src)
+		for j, line := range sheetText(i, s) {
+			label(swatch.Max.X+padding, top+int(fontSize)+j*sheetLineH, html.EscapeString(line))
+		}
+		if sheetShades > 0 {
+			for j, c := range shadeRow(s) {
+				x := padding + j*sheetShadeW
+				shade := image.Rect(x, top+sheetSwatch+sheetGap, x+sheetShadeW, top+sheetSwatch+sheetGap+sheetShadeW)
+				draw.Draw(sheet, shade, image.NewUniform(c), image.Point{}, draw.Src)
+			}
+		}
+	}
+	return sheet
+}
+
+// exportSheetPNG generates a PNG swatch sheet
+func exportSheetPNG() []byte {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, renderSheet()); err != nil {
+		panic(err)
+	}
+	return buf.Bytes()
+}
+
+// exportSheetSVG generates a vector swatch sheet
+func exportSheetSVG() []byte {
+	output := fmt.Sprintf("<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" viewBox=\"0 0 %d %d\">", sheetW, sheetH(), sheetW, sheetH())
+	output = fmt.Sprintf("%s\n  <rect width=\"100%%\" height=\"100%%\" fill=\"#FFFFFF\"/>", output)
+	output = fmt.Sprintf("%s\n  <g font-family=\"monospace\" font-size=\"%.0f\" fill=\"#000000\">", output, fontSize)
+	output = fmt.Sprintf("%s\n    <text x=\"%d\" y=\"%d\">%s</text>\n  </g>", output, padding, padding+int(fontSize), html.EscapeString(sheetParams()))
+	output = fmt.Sprintf("%s\n  <defs>\n    %s\n  </defs>", output, svgGradient("gradient"))
+	output = fmt.Sprintf("%s\n  <rect x=\"%d\" y=\"%d\" width=\"%d\" height=\"%d\" fill=\"url(#gradient)\"/>", output, padding, sheetBarY, sheetW-padding*2, sheetBarH)
+	for i, s := range activeStops() {
+		top := sheetHeaderH + i*sheetRowH
+		output = fmt.Sprintf("%s\n  <g id=\"%s\">", output, stopName(i))
+		output = fmt.Sprintf("%s\n    <rect x=\"%d\" y=\"%d\" width=\"%d\" height=\"%d\" fill=\"%s\"/>", output, padding, top, sheetSwatch, sheetSwatch, s.hex())
+		output = fmt.Sprintf("%s\n    <g font-family=\"monospace\" font-size=\"%.0f\" fill=\"#000000\">", output, fontSize)
+		for j, line := range sheetText(i, s) {
+			output = fmt.Sprintf("%s\n      <text x=\"%d\" y=\"%d\">%s</text>", output, padding*2+sheetSwatch, top+int(fontSize)+j*sheetLineH, line)
+		}
+		output += "\n    </g>"
+		if sheetShades > 0 {
+			for j, c := range shadeRow(s) {
+				x := padding + j*sheetShadeW
+				output = fmt.Sprintf("%s\n    <rect x=\"%d\" y=\"%d\" width=\"%d\" height=\"%d\" fill=\"#%02X%02X%02X\"/>", output, x, top+sheetSwatch+sheetGap, sheetShadeW, sheetShadeW, c.R, c.G, c.B)
+			}
+		}
+		output += "\n  </g>"
+	}
+	return []byte(output + "\n</svg>\n")
+}
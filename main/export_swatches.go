@@ -0,0 +1,56 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// swatchesMax is the number of slots in a Procreate palette
+const swatchesMax = 30
+
+// procreateSwatch is a single HSB color in a Procreate palette
+type procreateSwatch struct {
+	Hue        float64 `json:"hue"`
+	Saturation float64 `json:"saturation"`
+	Brightness float64 `json:"brightness"`
+	Alpha      float64 `json:"alpha"`
+	ColorSpace int     `json:"colorSpace"`
+}
+
+// procreatePalette is the document stored in Swatches.json
+type procreatePalette struct {
+	Name     string             `json:"name"`
+	Swatches []*procreateSwatch `json:"swatches"`
+}
+
+// exportSwatches generates a zipped Procreate palette,
+// swatches carry no name so edited stops can't be marked
+func exportSwatches() []byte {
+	palette := procreatePalette{Name: paletteName()}
+	for i, s := range activeStops() {
+		if i == swatchesMax {
+			break
+		}
+		palette.Swatches = append(palette.Swatches, &procreateSwatch{
+			Hue:        s.hue / 360,
+			Saturation: s.sat,
+			Brightness: s.bri,
+			Alpha:      1,
+		})
+	}
+	data, err := json.Marshal([]procreatePalette{palette})
+	if err != nil {
+		panic(err)
+	}
+	return zipFiles([]zipFile{{name: "Swatches.json", data: data}})
+}
+
+// exportHex generates a Lospec hex list,
+// Lospec reads nothing but the colors so edited stops can't be marked
+func exportHex() []byte {
+	output := ""
+	for _, s := range activeStops() {
+		output = fmt.Sprintf("%s%02x%02x%02x\n", output, s.r, s.g, s.b)
+	}
+	return []byte(output)
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/jpeg" // register the JPEG decoder for extraction
+	_ "image/png"  // register the PNG decoder for extraction
+	"math"
+	"math/rand"
+	"os"
+	"sort"
+)
+
+var (
+	extractSamples    = 20000 // maximum number of pixels considered
+	extractIterations = 20    // k-means iterations
+)
+
+// imageFilter matches the image types that can be opened
+const imageFilter = "*.png *.jpg *.jpeg"
+
+// readImage decodes a PNG or JPEG file
+func readImage(filename string) (image.Image, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	return img, err
+}
+
+// readExtract extracts the dominant colors of an image file and shows them as the stop set,
+// clearing the import afterwards leaves a golden palette seeded from the most prominent color
+func readExtract(filename string, median bool) error {
+	img, err := readImage(filename)
+	if err != nil {
+		return err
+	}
+	var colors []color.RGBA
+	if median {
+		colors = extractMedianCut(img, stops)
+	} else {
+		colors = extractKMeans(img, stops)
+	}
+	// fully transparent images have no pixels to extract from
+	if len(colors) == 0 {
+		return fmt.Errorf("%s: no colors found", filename)
+	}
+	return setImport(colors)
+}
+
+// samplePixels collects up to extractSamples evenly spaced pixels from the image
+func samplePixels(img image.Image) []color.RGBA {
+	b := img.Bounds()
+	stride := int(math.Ceil(math.Sqrt(float64(b.Dx()*b.Dy()) / float64(extractSamples))))
+	if stride < 1 {
+		stride = 1
+	}
+	var pixels []color.RGBA
+	for y := b.Min.Y; y < b.Max.Y; y += stride {
+		for x := b.Min.X; x < b.Max.X; x += stride {
+			if _, _, _, a := img.At(x, y).RGBA(); a == 0 {
+				continue
+			}
+			pixels = append(pixels, toRGBA(img.At(x, y)))
+		}
+	}
+	return pixels
+}
+
+// extractKMeans clusters the image in L*a*b* returning n colors, most prominent first
+func extractKMeans(img image.Image, n int) []color.RGBA {
+	pixels := samplePixels(img)
+	if len(pixels) == 0 {
+		return nil
+	}
+	points := make([]labColor, len(pixels))
+	for i, p := range pixels {
+		points[i] = rgbToLab(p)
+	}
+	if n > len(points) {
+		n = len(points)
+	}
+
+	// k-means++ seeding, with a fixed seed so the same image always gives the same palette
+	rnd := rand.New(rand.NewSource(1))
+	centers := []labColor{points[rnd.Intn(len(points))]}
+	dist := make([]float64, len(points))
+	for len(centers) < n {
+		total := 0.0
+		for i, p := range points {
+			dist[i] = math.Inf(1)
+			for _, c := range centers {
+				d := deltaE76(p, c)
+				dist[i] = math.Min(dist[i], d*d)
+			}
+			total += dist[i]
+		}
+		pick := rnd.Float64() * total
+		next := len(points) - 1
+		for i, d := range dist {
+			if pick -= d; pick <= 0 {
+				next = i
+				break
+			}
+		}
+		centers = append(centers, points[next])
+	}
+
+	assign := make([]int, len(points))
+	counts := make([]int, n)
+	for iter := 0; iter < extractIterations; iter++ {
+		// assign every point to its closest center
+		for i := range counts {
+			counts[i] = 0
+		}
+		for i, p := range points {
+			assign[i] = nearestLab(p, centers)
+			counts[assign[i]]++
+		}
+		// move the centers to the mean of their points
+		sums := make([]labColor, n)
+		for i, p := range points {
+			sums[assign[i]].l += p.l
+			sums[assign[i]].a += p.a
+			sums[assign[i]].b += p.b
+		}
+		for i := range centers {
+			if counts[i] > 0 {
+				centers[i] = labColor{sums[i].l / float64(counts[i]), sums[i].a / float64(counts[i]), sums[i].b / float64(counts[i])}
+			}
+		}
+	}
+
+	colors := make([]color.RGBA, n)
+	for i, c := range centers {
+		colors[i] = labToRGB(c)
+	}
+	sortByCount(colors, counts)
+	return colors
+}
+
+// nearestLab returns the index of the closest color in the list
+func nearestLab(p labColor, list []labColor) int {
+	best, bestD := 0, math.Inf(1)
+	for i, c := range list {
+		if d := deltaE76(p, c); d < bestD {
+			best, bestD = i, d
+		}
+	}
+	return best
+}
+
+// extractMedianCut splits the image colors into n boxes, returning their averages, largest box first
+func extractMedianCut(img image.Image, n int) []color.RGBA {
+	pixels := samplePixels(img)
+	if len(pixels) == 0 {
+		return nil
+	}
+	boxes := [][]color.RGBA{pixels}
+	for len(boxes) < n {
+		// split the box with the widest channel range
+		widest, channel, span := -1, 0, 0
+		for i, box := range boxes {
+			if len(box) < 2 {
+				continue
+			}
+			for ch := 0; ch < 3; ch++ {
+				lo, hi := 255, 0
+				for _, p := range box {
+					v := channelOf(p, ch)
+					if v < lo {
+						lo = v
+					}
+					if v > hi {
+						hi = v
+					}
+				}
+				if hi-lo > span || widest < 0 {
+					widest, channel, span = i, ch, hi-lo
+				}
+			}
+		}
+		if widest < 0 {
+			break
+		}
+		box := boxes[widest]
+		sort.Slice(box, func(a, b int) bool { return channelOf(box[a], channel) < channelOf(box[b], channel) })
+		mid := len(box) / 2
+		boxes = append(boxes[:widest], append([][]color.RGBA{box[:mid], box[mid:]}, boxes[widest+1:]...)...)
+	}
+
+	colors := make([]color.RGBA, len(boxes))
+	counts := make([]int, len(boxes))
+	for i, box := range boxes {
+		var r, g, b int
+		for _, p := range box {
+			r, g, b = r+int(p.R), g+int(p.G), b+int(p.B)
+		}
+		colors[i] = color.RGBA{uint8(r / len(box)), uint8(g / len(box)), uint8(b / len(box)), 255}
+		counts[i] = len(box)
+	}
+	sortByCount(colors, counts)
+	return colors
+}
+
+// channelOf returns the red, green or blue channel by index
+func channelOf(c color.RGBA, ch int) int {
+	switch ch {
+	case 0:
+		return int(c.R)
+	case 1:
+		return int(c.G)
+	}
+	return int(c.B)
+}
+
+// sortByCount orders the colors by their counts, largest first
+func sortByCount(colors []color.RGBA, counts []int) {
+	order := make([]int, len(colors))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return counts[order[a]] > counts[order[b]] })
+	sorted := make([]color.RGBA, len(colors))
+	for i, o := range order {
+		sorted[i] = colors[o]
+	}
+	for i := range colors {
+		colors[i] = sorted[i]
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// bandImage fills an image with horizontal bands of the colors, each as many rows tall as its weight
+func bandImage(colors []color.RGBA, weights []int) *image.RGBA {
+	h := 0
+	for _, w := range weights {
+		h += w
+	}
+	img := image.NewRGBA(image.Rect(0, 0, 40, h))
+	y := 0
+	for i, c := range colors {
+		for n := 0; n < weights[i]; n++ {
+			for x := 0; x < 40; x++ {
+				img.SetRGBA(x, y, c)
+			}
+			y++
+		}
+	}
+	return img
+}
+
+func TestExtract(t *testing.T) {
+	red, blue, yellow, green := color.RGBA{200, 40, 30, 255}, color.RGBA{20, 90, 180, 255}, color.RGBA{240, 220, 120, 255}, color.RGBA{30, 160, 60, 255}
+	tests := []struct {
+		name    string
+		extract func(image.Image, int) []color.RGBA
+		colors  []color.RGBA
+		weights []int
+		first   color.RGBA // largest band, when there is one
+	}{
+		{"k-means", extractKMeans, []color.RGBA{blue, red, yellow}, []int{30, 50, 20}, red},
+		// median cut halves boxes by pixel count, so only equal bands split cleanly
+		{"median cut", extractMedianCut, []color.RGBA{red, blue, yellow, green}, []int{25, 25, 25, 25}, color.RGBA{}},
+	}
+	for _, test := range tests {
+		got := test.extract(bandImage(test.colors, test.weights), len(test.colors))
+		if len(got) != len(test.colors) {
+			t.Errorf("%s: %d colors, want %d", test.name, len(got), len(test.colors))
+			continue
+		}
+		// the bands are flat, so every color comes back exactly
+		for _, c := range test.colors {
+			found := false
+			for _, g := range got {
+				found = found || g == c
+			}
+			if !found {
+				t.Errorf("%s: %v missing from %v", test.name, c, got)
+			}
+		}
+		// and the largest band comes first
+		if test.first != (color.RGBA{}) && got[0] != test.first {
+			t.Errorf("%s: first color %v, want %v", test.name, got[0], test.first)
+		}
+	}
+}
+
+func TestExtractTransparent(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 16, 16))
+	if got := extractKMeans(img, 4); len(got) != 0 {
+		t.Errorf("k-means found %v", got)
+	}
+	if got := extractMedianCut(img, 4); len(got) != 0 {
+		t.Errorf("median cut found %v", got)
+	}
+
+	dir, err := ioutil.TempDir("", "extract")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "clear.png")
+	f, err := os.Create(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := png.Encode(f, img); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	for _, median := range []bool{false, true} {
+		if err := readExtract(filename, median); err == nil {
+			t.Errorf("median %v: expected an error for a transparent image", median)
+		}
+	}
+	if err := setImport(nil); err == nil {
+		t.Error("expected an error importing no colors")
+	}
+}
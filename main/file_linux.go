@@ -1,4 +1,4 @@
-// Originally part of github.com/gen2brain/dlgs but we only need file save
+// Originally part of github.com/gen2brain/dlgs but we need file save
 // and unfortunately that is one feature that library does not support
 // TODO: determine how we should handle the two major OSes (OSX, Win10)
 // +build linux,!windows,!darwin,!js
@@ -26,8 +26,23 @@ func cmdPath() (string, error) {
 	return cmd, err
 }
 
-// File displays a file dialog, returning the selected file/directory and a bool for success.
+// File displays a file save dialog, returning the selected file/directory and a bool for success.
 func File(title, filter string, directory bool) (string, bool, error) {
+	return fileSelection(title, filter, "", directory, true)
+}
+
+// FileNamed displays a file save dialog suggesting filename, returning the selected file and a bool for success.
+func FileNamed(title, filter, filename string) (string, bool, error) {
+	return fileSelection(title, filter, filename, false, true)
+}
+
+// FileOpen displays a file open dialog, returning the selected file/directory and a bool for success.
+func FileOpen(title, filter string, directory bool) (string, bool, error) {
+	return fileSelection(title, filter, "", directory, false)
+}
+
+// fileSelection runs the file dialog in either save or open mode.
+func fileSelection(title, filter, filename string, directory, save bool) (string, bool, error) {
 	cmd, err := cmdPath()
 	if err != nil {
 		return "", false, err
@@ -43,7 +58,15 @@ func File(title, filter string, directory bool) (string, bool, error) {
 		fileFilter = "--file-filter=" + filter
 	}
 
-	o, err := exec.Command(cmd, "--file-selection", "--save", "--confirm-overwrite", "--title", title, fileFilter, dir).Output()
+	args := []string{"--file-selection", "--title", title, fileFilter, dir}
+	if save {
+		args = append(args, "--save", "--confirm-overwrite")
+	}
+	if filename != "" {
+		args = append(args, "--filename="+filename)
+	}
+
+	o, err := exec.Command(cmd, args...).Output()
 	if err != nil {
 		if exitError, ok := err.(*exec.ExitError); ok {
 			ws := exitError.Sys().(syscall.WaitStatus)
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten"
+)
+
+// gradient interpolation spaces
+const (
+	gradientSRGB = iota
+	gradientLinear
+	gradientOKLab
+	gradientSpaces
+)
+
+var (
+	gradientSpace   = gradientSRGB
+	gradientNames   = []string{"srgb", "srgb-linear", "oklab"}
+	gradientSamples = 8 // stops added between each pair of colors when not interpolating in sRGB
+
+	gradientH   = 12          // height of the preview strip under the color boxes
+	gradientImg *ebiten.Image // preview strip
+	gradientKey string        // palette and space the strip was drawn with
+)
+
+// gradientStop is a color at a position (0-1) along the gradient
+type gradientStop struct {
+	pos   float64
+	color color.RGBA
+}
+
+// mixSpace interpolates between two colors in the current gradient space
+func mixSpace(p, q color.RGBA, t float64) color.RGBA {
+	lerp := func(a, b float64) float64 {
+		return a + (b-a)*t
+	}
+	switch gradientSpace {
+	case gradientLinear:
+		return color.RGBA{
+			linearToSRGB(lerp(srgbToLinear(p.R), srgbToLinear(q.R))),
+			linearToSRGB(lerp(srgbToLinear(p.G), srgbToLinear(q.G))),
+			linearToSRGB(lerp(srgbToLinear(p.B), srgbToLinear(q.B))),
+			255,
+		}
+	case gradientOKLab:
+		a, b := rgbToOKLab(p), rgbToOKLab(q)
+		return okLabToRGB(okLabColor{lerp(a.l, b.l), lerp(a.a, b.a), lerp(a.b, b.b)})
+	}
+	return mixColor(p, q, t)
+}
+
+// gradientStops spreads the active stops evenly along the gradient,
+// adding intermediate stops so sRGB blending follows the chosen space
+func gradientStops() []gradientStop {
+	colors := gradientColors()
+	n := float64(len(colors) - 1)
+	var list []gradientStop
+	for i, c := range colors {
+		list = append(list, gradientStop{float64(i) / n, c})
+		if i == len(colors)-1 || gradientSpace == gradientSRGB {
+			continue
+		}
+		for j := 1; j < gradientSamples; j++ {
+			t := float64(j) / float64(gradientSamples)
+			list = append(list, gradientStop{(float64(i) + t) / n, mixSpace(c, colors[i+1], t)})
+		}
+	}
+	return list
+}
+
+// gradientAt calculates the color at a position (0-1) along the gradient through colors
+func gradientAt(colors []color.RGBA, pos float64) color.RGBA {
+	f := pos * float64(len(colors)-1)
+	i := int(f)
+	if i >= len(colors)-1 {
+		return colors[len(colors)-1]
+	}
+	return mixSpace(colors[i], colors[i+1], f-float64(i))
+}
+
+// renderGradient draws the gradient horizontally into a new image
+func renderGradient(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	colors := gradientColors()
+	for x := 0; x < w; x++ {
+		c := gradientAt(colors, float64(x)/float64(w-1))
+		for y := 0; y < h; y++ {
+			img.SetRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+// updateGradient redraws the preview strip when the palette or space has changed
+func updateGradient() {
+	key := fmt.Sprint(gradientColors(), gradientSpace)
+	if key == gradientKey {
+		return
+	}
+	gradientKey = key
+	gradientImg, _ = ebiten.NewImageFromImage(renderGradient(screenW-padding*2, gradientH), ebiten.FilterDefault)
+}
+
+// cssStops lists the gradient stops for a CSS gradient function
+func cssStops(unit string, scale float64) string {
+	list := gradientStops()
+	parts := make([]string, len(list))
+	for i, s := range list {
+		parts[i] = fmt.Sprintf("#%02x%02x%02x %.2f%s", s.color.R, s.color.G, s.color.B, s.pos*scale, unit)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// exportCSS generates CSS custom properties for each stop and the palette as gradients
+func exportCSS() []byte {
+	output := fmt.Sprintf("/* %s */\n:root {", plainName(paletteName()))
+	for i, s := range activeStops() {
+		output = fmt.Sprintf("%s\n  --%s: %s;", output, strings.ToLower(stopName(i)), strings.ToLower(s.hex()))
+		if stopEdited(i) {
+			output += " /* edited */"
+		}
+	}
+	output = fmt.Sprintf("%s\n  --linear-gradient: linear-gradient(to right, %s);", output, cssStops("%", 100))
+	output = fmt.Sprintf("%s\n  --conic-gradient: conic-gradient(%s);", output, cssStops("deg", 360))
+	return []byte(output + "\n}\n")
+}
+
+// exportGGR generates a GIMP gradient with one linear RGB segment between each pair of stops
+func exportGGR() []byte {
+	list := gradientStops()
+	output := fmt.Sprintf("GIMP Gradient\nName: %s\n%d", paletteName(), len(list)-1)
+	unit := func(v uint8) float64 {
+		return float64(v) / 255
+	}
+	for i := 0; i < len(list)-1; i++ {
+		l, r := list[i], list[i+1]
+		output = fmt.Sprintf("%s\n%.6f %.6f %.6f %.6f %.6f %.6f 1.000000 %.6f %.6f %.6f 1.000000 0 0", output,
+			l.pos, (l.pos+r.pos)/2, r.pos,
+			unit(l.color.R), unit(l.color.G), unit(l.color.B),
+			unit(r.color.R), unit(r.color.G), unit(r.color.B))
+	}
+	return []byte(output + "\n")
+}
+
+// svgGradient generates an SVG linearGradient definition with the given id
+func svgGradient(id string) string {
+	output := fmt.Sprintf("<linearGradient id=\"%s\" x1=\"0\" y1=\"0\" x2=\"1\" y2=\"0\">", id)
+	for _, s := range gradientStops() {
+		output = fmt.Sprintf("%s\n      <stop offset=\"%.4f\" stop-color=\"#%02X%02X%02X\"/>", output, s.pos, s.color.R, s.color.G, s.color.B)
+	}
+	return output + "\n    </linearGradient>"
+}
+
+// exportGradientSVG generates an SVG of the gradient alone, with the gradient in its defs for reuse
+func exportGradientSVG() []byte {
+	w, h := sheetW-padding*2, sheetBarH
+	output := fmt.Sprintf("<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" viewBox=\"0 0 %d %d\">", w, h, w, h)
+	output = fmt.Sprintf("%s\n  <title>%s</title>", output, html.EscapeString(paletteName()))
+	output = fmt.Sprintf("%s\n  <defs>\n    %s\n  </defs>", output, svgGradient("gradient"))
+	output = fmt.Sprintf("%s\n  <rect width=\"100%%\" height=\"100%%\" fill=\"url(#gradient)\"/>", output)
+	return []byte(output + "\n</svg>\n")
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"math"
+
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/ebitenutil"
+)
+
+var (
+	// overrides place stops, by index, where their guide lines were dragged, breaking the golden sequence
+	overrides = map[int]float64{}
+
+	hoverStop = -1 // stop whose guide line is under the cursor
+	grabStop  = -1 // stop whose guide line is being dragged
+
+	grabRange       = 4.0  // pixels either side of a guide line that grab it
+	ratioProbe      = 0.01 // first step taken when back-solving the ratio
+	ratioIterations = 8    // secant steps per frame when back-solving the ratio
+	ratioMin        = 0.1  // the ratio is kept within these so the stops stay spread out
+	ratioMax        = 4.0
+	guideDash       = 8.0 // length of the dashes of overridden guide lines
+	guideHoverW     = 3   // width of a highlighted guide line
+)
+
+// stopAt returns the stop whose guide line is nearest x, or -1 when none is within grabRange
+func stopAt(x int) int {
+	found, nearest := -1, grabRange
+	for i := 0; i < stops; i++ {
+		if d := wrapDiff(stoplist[i].off, float64(x)); d <= nearest {
+			found, nearest = i, d
+		}
+	}
+	return found
+}
+
+// wrapDiff is the distance between two picker positions going the short way around the hue circle
+func wrapDiff(a, b float64) float64 {
+	d := math.Mod(math.Abs(a-b), float64(screenW))
+	return math.Min(d, float64(screenW)-d)
+}
+
+// nearestWrap shifts v by whole picker widths to be as close as possible to near
+func nearestWrap(v, near float64) float64 {
+	sW := float64(screenW)
+	return v + sW*math.Round((near-v)/sW)
+}
+
+// dragGuide moves the grabbed guide line to x,
+// later stops are overridden unless solve asks for the ratio that puts them there
+func dragGuide(x int, solve bool) {
+	switch {
+	case grabStop == 0:
+		primary = x
+	case grabStop == 1:
+		distance = int(nearestWrap(float64(x-primary), float64(distance)))
+	case solve:
+		delete(overrides, grabStop)
+		ratio = solveRatio(grabStop, float64(x))
+	default:
+		// keep the unwrapped position near the sequence so the stops after it follow on smoothly
+		seq := nextStop(stoplist[grabStop-2].val, stoplist[grabStop-1].val, ratio)
+		overrides[grabStop] = nearestWrap(float64(x), seq)
+	}
+}
+
+// sequenceVal is the unwrapped position of stop i for a ratio, following any overrides
+func sequenceVal(i int, r float64) float64 {
+	a, b := float64(primary), float64(primary+distance)
+	if i == 0 {
+		return a
+	}
+	for j := 2; j <= i; j++ {
+		next := nextStop(a, b, r)
+		if v, ok := overrides[j]; ok {
+			next = v
+		}
+		a, b = b, next
+	}
+	return b
+}
+
+// solveRatio finds the ratio that puts stop i at x, taking secant steps from the current ratio
+// so the stop follows the cursor to the nearest matching position
+func solveRatio(i int, x float64) float64 {
+	target := nearestWrap(x, sequenceVal(i, ratio))
+	r0, r1 := ratio, ratio+ratioProbe
+	f0, f1 := sequenceVal(i, r0)-target, sequenceVal(i, r1)-target
+	for n := 0; n < ratioIterations && math.Abs(f1) > 0.5 && f1 != f0; n++ {
+		r0, r1, f0 = r1, r1-f1*(r1-r0)/(f1-f0), f1
+		r1 = math.Max(ratioMin, math.Min(ratioMax, r1))
+		f1 = sequenceVal(i, r1) - target
+	}
+	if math.Abs(f1) > math.Abs(f0) {
+		return r0
+	}
+	return r1
+}
+
+// releaseGuides puts every dragged guide line back in the sequence and restores the golden ratio
+func releaseGuides() {
+	overrides = map[int]float64{}
+	ratio = 1
+}
+
+// copyOverrides returns an independent copy of the overrides
+func copyOverrides() map[int]float64 {
+	c := make(map[int]float64, len(overrides))
+	for i, v := range overrides {
+		c[i] = v
+	}
+	return c
+}
+
+// sameOverrides reports whether two sets of overrides place the same stops
+func sameOverrides(a, b map[int]float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i, v := range a {
+		if w, ok := b[i]; !ok || w != v {
+			return false
+		}
+	}
+	return true
+}
+
+// drawGuide draws the guide line of stop i, dashed when overridden and wider when it can be grabbed
+func drawGuide(screen *ebiten.Image, i int) {
+	c := stoplist[i].negative()
+	w := 1
+	if i == hoverStop || i == grabStop || i == selected {
+		w = guideHoverW
+	}
+	for dx := 0; dx < w; dx++ {
+		x := stoplist[i].off + float64(dx-w/2)
+		if _, ok := overrides[i]; !ok {
+			ebitenutil.DrawLine(screen, x, 0, x, float64(pickerH), c)
+			continue
+		}
+		for y := 0.0; y < float64(pickerH); y += guideDash * 2 {
+			ebitenutil.DrawLine(screen, x, y, x, math.Min(y+guideDash, float64(pickerH)), c)
+		}
+	}
+}
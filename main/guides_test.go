@@ -0,0 +1,72 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNearestWrap(t *testing.T) {
+	w := float64(screenW)
+	tests := []struct {
+		v, near, want float64
+	}{
+		{10, 20, 10},
+		{10, w + 5, w + 10},
+		{w - 10, 5, -10},
+		{30, -w, 30 - w},
+	}
+	for _, test := range tests {
+		if got := nearestWrap(test.v, test.near); got != test.want {
+			t.Errorf("nearestWrap(%g, %g) = %g, want %g", test.v, test.near, got, test.want)
+		}
+	}
+}
+
+func TestSolveRatio(t *testing.T) {
+	defer releaseGuides()
+	tests := []struct {
+		name      string
+		stop      int
+		from, to  float64
+		overrides map[int]float64
+	}{
+		{"third stop wider", 2, 1, 1.2, nil},
+		{"third stop narrower", 2, 1, 0.8, nil},
+		{"fifth stop", 4, 1, 1.1, nil},
+		{"from a changed ratio", 3, 1.3, 1.25, nil},
+		{"after an override", 4, 1, 0.9, map[int]float64{2: 330}},
+	}
+	for _, test := range tests {
+		primary, distance, stops = 100, 60, 6
+		releaseGuides()
+		for i, v := range test.overrides {
+			overrides[i] = v
+		}
+		x := math.Mod(sequenceVal(test.stop, test.to), float64(screenW))
+		ratio = test.from
+		got := solveRatio(test.stop, x)
+		if d := wrapDiff(sequenceVal(test.stop, got), x); d > 1 {
+			t.Errorf("%s: ratio %.4f puts the stop %.2f from %g", test.name, got, d, x)
+		}
+		if got < ratioMin || got > ratioMax {
+			t.Errorf("%s: ratio %.4f out of range", test.name, got)
+		}
+	}
+}
+
+func TestDragGuideSolve(t *testing.T) {
+	defer releaseGuides()
+	defer func(g int) { grabStop = g }(grabStop)
+	primary, distance, stops = 100, 60, 6
+	releaseGuides()
+	x := int(math.Round(math.Mod(sequenceVal(3, 1.1), float64(screenW))))
+	overrides[3] = 500
+	grabStop = 3
+	dragGuide(x, true)
+	if _, ok := overrides[3]; ok {
+		t.Error("back-solving kept the override of the dragged stop")
+	}
+	if math.Abs(ratio-1.1) > 0.01 {
+		t.Errorf("ratio %.4f, want 1.1", ratio)
+	}
+}
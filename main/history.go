@@ -0,0 +1,164 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/ebitenutil"
+)
+
+// snapshot holds the generator parameters at one point in the history
+type snapshot struct {
+	primary, distance, brightness, step, stops int
+	ratio                                      float64
+	overrides                                  map[int]float64
+	edits                                      []stopEdit
+	colors                                     []color.RGBA // stop colors, for the thumbnail
+}
+
+var (
+	history        []snapshot
+	historyPos     = -1  // index of the current state in history
+	historyMax     = 200 // oldest entries are dropped past this
+	historyScroll  int   // first thumbnail shown in the strip
+	historyVisible bool
+	historyWheel   int  // frames left before wheel changes are recorded
+	historySettle  = 20 // frames without wheel movement that end a scroll
+
+	historyThumbW = 80
+	historyThumbH = 24
+	historyGap    = 4
+)
+
+// current captures the generator parameters
+func current() snapshot {
+	return snapshot{primary, distance, brightness, step, stops, ratio, copyOverrides(), copyEdits(), paletteColors()}
+}
+
+// same reports whether two snapshots hold the same parameters
+func (s snapshot) same(o snapshot) bool {
+	return s.primary == o.primary && s.distance == o.distance && s.brightness == o.brightness && s.step == o.step && s.stops == o.stops && s.ratio == o.ratio && sameOverrides(s.overrides, o.overrides) && sameEdits(s.edits, o.edits)
+}
+
+// restore sets the generator parameters from the snapshot
+func (s snapshot) restore() {
+	primary, distance, brightness, step, stops = s.primary, s.distance, s.brightness, s.step, s.stops
+	ratio = s.ratio
+	overrides = make(map[int]float64, len(s.overrides))
+	for i, v := range s.overrides {
+		overrides[i] = v
+	}
+	setEdits(s.edits)
+}
+
+// recordHistory adds the current parameters to the history when they have changed,
+// nothing is recorded mid-drag or mid-scroll so each becomes one entry
+func recordHistory() {
+	if dragging {
+		return
+	}
+	// a scroll is recorded once the wheel settles, like a drag once it is released
+	if historyWheel > 0 {
+		historyWheel--
+		return
+	}
+	now := current()
+	if historyPos >= 0 && history[historyPos].same(now) {
+		return
+	}
+	// a new change drops anything that was undone
+	history = append(history[:historyPos+1], now)
+	if len(history) > historyMax {
+		history = history[len(history)-historyMax:]
+	}
+	historyPos = len(history) - 1
+	// keep the newest entry in view
+	if historyPos >= historyScroll+historyVisibleCount() {
+		historyScroll = historyPos - historyVisibleCount() + 1
+	}
+}
+
+// settleHistory records a scroll that is still settling, so undo starts from it
+func settleHistory() {
+	if historyWheel > 0 {
+		historyWheel = 0
+		recordHistory()
+	}
+}
+
+// undo steps back through the history
+func undo() {
+	settleHistory()
+	if historyPos > 0 {
+		historyPos--
+		history[historyPos].restore()
+	}
+}
+
+// redo steps forward through the history
+func redo() {
+	settleHistory()
+	if historyPos < len(history)-1 {
+		historyPos++
+		history[historyPos].restore()
+	}
+}
+
+// historyVisibleCount is the number of thumbnails that fit in the strip
+func historyVisibleCount() int {
+	return (screenW - padding*2) / (historyThumbW + historyGap)
+}
+
+// scrollHistory moves the strip by the wheel delta
+func scrollHistory(delta float64) {
+	historyScroll -= int(math.Round(delta))
+	if max := len(history) - historyVisibleCount(); historyScroll > max {
+		historyScroll = max
+	}
+	if historyScroll < 0 {
+		historyScroll = 0
+	}
+}
+
+// historyThumbRect is the screen area of the thumbnail at index i
+func historyThumbRect(i int) image.Rectangle {
+	x := padding + (i-historyScroll)*(historyThumbW+historyGap)
+	y := pickerH + padding
+	return image.Rect(x, y, x+historyThumbW, y+historyThumbH)
+}
+
+// clickHistory jumps to the thumbnail under the cursor, reporting whether one was clicked
+func clickHistory(cursor image.Point) bool {
+	if !historyVisible {
+		return false
+	}
+	for i := historyScroll; i < len(history) && i < historyScroll+historyVisibleCount(); i++ {
+		if cursor.In(historyThumbRect(i)) {
+			settleHistory()
+			historyPos = i
+			history[i].restore()
+			return true
+		}
+	}
+	return false
+}
+
+// drawHistory draws the strip of palette thumbnails over the output area
+func drawHistory(screen *ebiten.Image) {
+	if !historyVisible {
+		return
+	}
+	for i := historyScroll; i < len(history) && i < historyScroll+historyVisibleCount(); i++ {
+		r := historyThumbRect(i)
+		// outline the current state
+		if i == historyPos {
+			ebitenutil.DrawRect(screen, float64(r.Min.X-2), float64(r.Min.Y-2), float64(r.Dx()+4), float64(r.Dy()+4), color.White)
+		}
+		w := float64(r.Dx()) / float64(len(history[i].colors))
+		for j, c := range history[i].colors {
+			ebitenutil.DrawRect(screen, float64(r.Min.X)+float64(j)*w, float64(r.Min.Y), math.Ceil(w), float64(r.Dy()), c)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/ebitenutil"
+	"github.com/hajimehoshi/ebiten/text"
+	"golang.org/x/image/font"
+)
+
+var (
+	debugHUD  bool      // show frame rates and the cursor position
+	hudHidden bool      // the status lines are hidden to see the whole picker
+	helpFont  font.Face // smaller arcade face for the help panel
+
+	hudLineH  = 20
+	helpLineH = 12
+	hudText   = color.RGBA{0xee, 0xee, 0xee, 0xff}
+	hudBack   = color.RGBA{0x11, 0x11, 0x11, 0xcc}
+)
+
+// hueDegrees converts a distance across the picker to degrees of hue
+func hueDegrees(d int) int {
+	return d * 360 / pickerW
+}
+
+// hudMode describes what the stops are following
+func hudMode() string {
+	switch {
+	case entry.active:
+		return "typing"
+	case dragging:
+		return "dragging"
+	case libraryVisible:
+		return "library"
+	case len(importList) > 0:
+		return "imported"
+	case previewImg != nil:
+		return "recolor"
+	case len(anchors) > 0:
+		return "anchored"
+	case len(overrides) > 0:
+		return "overridden"
+	}
+	return "golden"
+}
+
+// hudLines are the status lines shown over the picker
+func hudLines() []string {
+	lines := []string{
+		fmt.Sprintf("%s  stops %d  step %d  distance %d deg  brightness %d  ratio x%.3f", hudMode(), stops, step, hueDegrees(distance), brightness, ratio),
+		paletteName(),
+		fmt.Sprintf("snap %s  gradient %s  lut %d %s", snapName(), gradientNames[gradientSpace], lutSize, lutNames[lutMode]),
+		"colormap " + colormapStatus(),
+	}
+	if randomSeed >= 0 {
+		lines = append(lines, fmt.Sprintf("seed %d  constraints %s", randomSeed, randomConstraints()))
+	}
+	if selected >= 0 {
+		lines = append(lines, fmt.Sprintf("stop %d  %s", selected+1, stoplist[selected].edit))
+	}
+	if locateDE > 0 || len(anchors) > 0 {
+		lines = append(lines, fmt.Sprintf("dE %.2f  fit %.2f", locateDE, anchorDE))
+	}
+	if entry.active {
+		lines = append(lines, entry.String())
+	}
+	if a := findAction("help"); a != nil && len(a.keys) > 0 && !helpVisible {
+		lines = append(lines, a.keys[0]+" help")
+	}
+	if debugHUD {
+		px, py := ebiten.CursorPosition()
+		lines = append(lines, fmt.Sprintf("fps %.1f  tps %.1f  x %d  y %d", ebiten.CurrentFPS(), ebiten.CurrentTPS(), px, py))
+	}
+	return lines
+}
+
+// drawLines draws text on a dark backing so it reads over any color
+func drawLines(screen *ebiten.Image, lines []string, face font.Face, x, y, lineH int) {
+	w := 0
+	for _, line := range lines {
+		if b := text.BoundString(face, line); b.Dx() > w {
+			w = b.Dx()
+		}
+	}
+	ebitenutil.DrawRect(screen, float64(x-padding/2), float64(y-padding/2), float64(w+padding), float64(len(lines)*lineH+padding/2), hudBack)
+	for i, line := range lines {
+		text.Draw(screen, line, face, x, y+i*lineH+face.Metrics().Ascent.Ceil(), hudText)
+	}
+}
+
+// drawHUD shows the status lines in the corner of the picker
+func drawHUD(screen *ebiten.Image) {
+	if hudHidden && !entry.active {
+		return
+	}
+	lines := hudLines()
+	for i := range lines {
+		lines[i] = strings.ToUpper(lines[i])
+	}
+	drawLines(screen, lines, arcadeFont, padding, padding, hudLineH)
+}
+
+// drawHelp lists the active key map in two columns over the window
+func drawHelp(screen *ebiten.Image) {
+	if !helpVisible {
+		return
+	}
+	lines := helpLines()
+	for i := range lines {
+		lines[i] = strings.ToUpper(lines[i])
+	}
+	rows := (len(lines) + 1) / 2
+	top := padding
+	if !hudHidden {
+		top += len(hudLines())*hudLineH + padding
+	}
+	drawLines(screen, lines[:rows], helpFont, padding, top, helpLineH)
+	drawLines(screen, lines[rows:], helpFont, padding+screenW/2, top, helpLineH)
+}
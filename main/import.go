@@ -0,0 +1,346 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image/color"
+	"io"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// importedStop is a color loaded from a palette file, placed on the picker
+type importedStop struct {
+	color color.RGBA
+	x, y  int     // nearest picker coordinates
+	dE    float64 // distance between the color and the picker at x, y
+}
+
+var (
+	// importList holds the imported palette, displayed in place of the first generated stops
+	importList []importedStop
+
+	// importers map file extensions to the function parsing their data
+	importers = map[string]func([]byte) ([]color.RGBA, error){
+		".ase": importASE,
+		".css": importCSS,
+		".gpl": importGPL,
+		".hex": importHex,
+		".pal": importPAL,
+		".txt": importPaintNET,
+	}
+
+	errUnknownFormat = errors.New("unknown palette format")
+	errNoColors      = errors.New("no colors to import")
+
+	cssVarRe = regexp.MustCompile(`--[\w-]+\s*:\s*(#[0-9a-fA-F]{3,8}|rgba?\([^)]*\))`)
+)
+
+// importFilter builds the file dialog filter from the supported extensions
+func importFilter() string {
+	exts := make([]string, 0, len(importers))
+	for ext := range importers {
+		exts = append(exts, ext)
+	}
+	return extFilter(exts)
+}
+
+// readImport parses the palette file and places its colors on the picker
+func readImport(filename string) error {
+	colors, err := readPalette(filename)
+	if err != nil {
+		return err
+	}
+	return setImport(colors)
+}
+
+// readPalette parses the colors from a palette file, the file extension determines its format
+func readPalette(filename string) ([]color.RGBA, error) {
+	parse, ok := importers[strings.ToLower(filepath.Ext(filename))]
+	if !ok {
+		return nil, errUnknownFormat
+	}
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	colors, err := parse(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(colors) == 0 {
+		return nil, fmt.Errorf("%s: no colors found", filename)
+	}
+	return colors, nil
+}
+
+// setImport places the colors on the picker and shows them as the stop set
+func setImport(colors []color.RGBA) error {
+	if len(colors) == 0 {
+		return errNoColors
+	}
+	if len(colors) > stopmax {
+		colors = colors[:stopmax]
+	}
+	importList = make([]importedStop, len(colors))
+	for i, c := range colors {
+		x, y, dE := nearestPickerPoint(c)
+		importList[i] = importedStop{color: c, x: x, y: y, dE: dE}
+	}
+	// seed the generator from the imported colors so extending the palette continues from them
+	primary = importList[0].x
+	brightness = importList[0].y
+	if len(importList) > 1 {
+		distance = importList[1].x - primary
+	}
+	stops = len(importList)
+	clampParams()
+	return nil
+}
+
+// importGPL parses a GIMP palette
+func importGPL(data []byte) ([]color.RGBA, error) {
+	lines := paletteLines(data)
+	if len(lines) == 0 || lines[0] != "GIMP Palette" {
+		return nil, errUnknownFormat
+	}
+	var colors []color.RGBA
+	for _, line := range lines[1:] {
+		if strings.HasPrefix(line, "#") || strings.Contains(line, ":") {
+			continue
+		}
+		if c, ok := parseRGBFields(strings.Fields(line)); ok {
+			colors = append(colors, c)
+		}
+	}
+	return colors, nil
+}
+
+// importPAL parses a JASC palette
+func importPAL(data []byte) ([]color.RGBA, error) {
+	lines := paletteLines(data)
+	if len(lines) < 3 || lines[0] != "JASC-PAL" {
+		return nil, errUnknownFormat
+	}
+	num, err := strconv.Atoi(lines[2])
+	if err != nil {
+		return nil, err
+	}
+	var colors []color.RGBA
+	for _, line := range lines[3:] {
+		if len(colors) == num {
+			break
+		}
+		if c, ok := parseRGBFields(strings.Fields(line)); ok {
+			colors = append(colors, c)
+		}
+	}
+	return colors, nil
+}
+
+// importHex parses a Lospec hex list, one color per line
+func importHex(data []byte) ([]color.RGBA, error) {
+	var colors []color.RGBA
+	for _, line := range paletteLines(data) {
+		if strings.HasPrefix(line, ";") || strings.HasPrefix(line, "//") {
+			continue
+		}
+		c, err := parseHex(line)
+		if err != nil {
+			return nil, err
+		}
+		colors = append(colors, c)
+	}
+	return colors, nil
+}
+
+// importPaintNET parses a paint.net palette, where every color is written as AARRGGBB
+func importPaintNET(data []byte) ([]color.RGBA, error) {
+	var colors []color.RGBA
+	for _, line := range paletteLines(data) {
+		if strings.HasPrefix(line, ";") {
+			continue
+		}
+		if len(line) != 8 {
+			return nil, fmt.Errorf("invalid paint.net color %q", line)
+		}
+		// the alpha comes first
+		c, err := parseHex(line[2:])
+		if err != nil {
+			return nil, err
+		}
+		colors = append(colors, c)
+	}
+	return colors, nil
+}
+
+// importCSS parses CSS custom properties holding hex or rgb() colors
+func importCSS(data []byte) ([]color.RGBA, error) {
+	var colors []color.RGBA
+	for _, match := range cssVarRe.FindAllSubmatch(data, -1) {
+		value := string(match[1])
+		if strings.HasPrefix(value, "#") {
+			c, err := parseHex(value)
+			if err != nil {
+				return nil, err
+			}
+			colors = append(colors, c)
+			continue
+		}
+		args := value[strings.Index(value, "(")+1 : len(value)-1]
+		if c, ok := parseRGBFields(strings.FieldsFunc(args, func(r rune) bool {
+			return r == ',' || r == ' ' || r == '/'
+		})); ok {
+			colors = append(colors, c)
+		}
+	}
+	return colors, nil
+}
+
+// importASE parses an Adobe Swatch Exchange file
+func importASE(data []byte) ([]color.RGBA, error) {
+	r := bytes.NewReader(data)
+	var header struct {
+		Signature [4]byte
+		Major     uint16
+		Minor     uint16
+		Blocks    uint32
+	}
+	if err := binary.Read(r, binary.BigEndian, &header); err != nil {
+		return nil, err
+	}
+	if string(header.Signature[:]) != "ASEF" {
+		return nil, errUnknownFormat
+	}
+	var colors []color.RGBA
+	for i := uint32(0); i < header.Blocks; i++ {
+		var block struct {
+			Type   uint16
+			Length uint32
+		}
+		if err := binary.Read(r, binary.BigEndian, &block); err != nil {
+			return nil, err
+		}
+		// the length comes from the file, so it can't be trusted past the end of the data
+		if int64(block.Length) > int64(r.Len()) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		body := make([]byte, block.Length)
+		if _, err := io.ReadFull(r, body); err != nil {
+			return nil, err
+		}
+		// only color entries matter, group start and end blocks are skipped
+		if block.Type != aseColorEntry {
+			continue
+		}
+		c, _, err := parseASEColor(body)
+		if err != nil {
+			return nil, err
+		}
+		colors = append(colors, c)
+	}
+	return colors, nil
+}
+
+// ASE block types
+const (
+	aseColorEntry uint16 = 0x0001
+	aseGroupStart uint16 = 0xC001
+	aseGroupEnd   uint16 = 0xC002
+)
+
+// parseASEColor reads the name and color of an ASE color entry block
+func parseASEColor(body []byte) (color.RGBA, string, error) {
+	r := bytes.NewReader(body)
+	var nameLen uint16
+	if err := binary.Read(r, binary.BigEndian, &nameLen); err != nil {
+		return color.RGBA{}, "", err
+	}
+	name := make([]uint16, nameLen)
+	if err := binary.Read(r, binary.BigEndian, name); err != nil {
+		return color.RGBA{}, "", err
+	}
+	var model [4]byte
+	if err := binary.Read(r, binary.BigEndian, &model); err != nil {
+		return color.RGBA{}, "", err
+	}
+	channels := map[string]int{"RGB ": 3, "CMYK": 4, "Gray": 1, "LAB ": 3}[string(model[:])]
+	if channels == 0 {
+		return color.RGBA{}, "", errUnknownFormat
+	}
+	values := make([]float32, channels)
+	if err := binary.Read(r, binary.BigEndian, values); err != nil {
+		return color.RGBA{}, "", err
+	}
+	label := strings.TrimRight(string(utf16.Decode(name)), "\x00")
+
+	var c color.RGBA
+	switch string(model[:]) {
+	case "RGB ":
+		c = color.RGBA{unitToByte(float64(values[0])), unitToByte(float64(values[1])), unitToByte(float64(values[2])), 255}
+	case "CMYK":
+		k := 1 - float64(values[3])
+		c = color.RGBA{unitToByte((1 - float64(values[0])) * k), unitToByte((1 - float64(values[1])) * k), unitToByte((1 - float64(values[2])) * k), 255}
+	case "Gray":
+		v := unitToByte(float64(values[0]))
+		c = color.RGBA{v, v, v, 255}
+	case "LAB ":
+		c = labToRGB(labColor{float64(values[0]) * 100, float64(values[1]), float64(values[2])})
+	}
+	return c, label, nil
+}
+
+// paletteLines splits palette text into trimmed, non-empty lines
+func paletteLines(data []byte) []string {
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// parseRGBFields reads the first three fields as 0-255 channel values
+func parseRGBFields(fields []string) (color.RGBA, bool) {
+	if len(fields) < 3 {
+		return color.RGBA{}, false
+	}
+	var rgb [3]uint8
+	for i := range rgb {
+		v, err := strconv.ParseFloat(strings.TrimSuffix(fields[i], "%"), 64)
+		if err != nil {
+			return color.RGBA{}, false
+		}
+		if strings.HasSuffix(fields[i], "%") {
+			v *= 2.55
+		}
+		rgb[i] = uint8(math.Round(math.Max(0, math.Min(255, v))))
+	}
+	return color.RGBA{rgb[0], rgb[1], rgb[2], 255}, true
+}
+
+// parseHex reads #RGB, #RRGGBB or #RRGGBBAA colors, with or without the hash
+func parseHex(s string) (color.RGBA, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "#")
+	if len(s) == 3 || len(s) == 4 {
+		s = string([]byte{s[0], s[0], s[1], s[1], s[2], s[2]})
+	}
+	if len(s) != 6 && len(s) != 8 {
+		return color.RGBA{}, fmt.Errorf("invalid hex color %q", s)
+	}
+	v, err := strconv.ParseUint(s[:6], 16, 32)
+	if err != nil {
+		return color.RGBA{}, err
+	}
+	return color.RGBA{uint8(v >> 16), uint8(v >> 8), uint8(v), 255}, nil
+}
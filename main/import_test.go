@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image/color"
+	"testing"
+)
+
+// setTestStops replaces the active stops with the given colors
+func setTestStops(colors []color.RGBA) {
+	stops = len(colors)
+	for i, c := range colors {
+		stoplist[i] = colorStop{}
+		stoplist[i].setColor(c)
+	}
+}
+
+func TestImportRoundTrip(t *testing.T) {
+	colors := []color.RGBA{{51, 102, 153, 255}, {255, 0, 0, 255}, {1, 2, 3, 255}, {250, 240, 230, 255}}
+	setTestStops(colors)
+	for ext, importer := range importers {
+		exporter, ok := exporters[ext]
+		if !ok {
+			continue
+		}
+		got, err := importer(exporter())
+		if err != nil {
+			t.Errorf("%s: %v", ext, err)
+			continue
+		}
+		// JASC palettes are always written with 16 entries, padded with black
+		if ext == ".pal" && len(got) == 16 {
+			for _, c := range got[len(colors):] {
+				if c != (color.RGBA{0, 0, 0, 255}) {
+					t.Errorf("%s: padding is %v, want black", ext, c)
+				}
+			}
+			got = got[:len(colors)]
+		}
+		if len(got) != len(colors) {
+			t.Errorf("%s: imported %d colors, want %d", ext, len(got), len(colors))
+			continue
+		}
+		for i := range colors {
+			if got[i] != colors[i] {
+				t.Errorf("%s: color %d is %v, want %v", ext, i, got[i], colors[i])
+			}
+		}
+	}
+}
+
+func TestImportASETruncated(t *testing.T) {
+	var data bytes.Buffer
+	data.WriteString("ASEF")
+	// version 1.0 with one block, a color entry claiming to be 4GB long
+	binary.Write(&data, binary.BigEndian, []uint16{1, 0})
+	binary.Write(&data, binary.BigEndian, uint32(1))
+	binary.Write(&data, binary.BigEndian, uint16(aseColorEntry))
+	binary.Write(&data, binary.BigEndian, uint32(0xffffffff))
+	if _, err := importASE(data.Bytes()); err == nil {
+		t.Error("expected an error for a block longer than the file")
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/inpututil"
+)
+
+// textEntry collects typed characters until enter or escape is pressed
+type textEntry struct {
+	active bool
+	prompt string
+	text   string
+	done   func(string)        // called with the text when enter is pressed
+	typed  map[ebiten.Key]bool // keys pressed while typing, whose release belongs to the entry
+}
+
+// entry is the text currently being typed, if any
+var entry textEntry
+
+// start begins collecting text, calling done with the result
+func (t *textEntry) start(prompt, text string, done func(string)) {
+	t.active = true
+	t.prompt = prompt
+	t.text = text
+	t.done = done
+}
+
+// update adds the characters typed since the last frame
+func (t *textEntry) update() {
+	if !t.active {
+		return
+	}
+	t.text += string(ebiten.InputChars())
+	// enter and escape end the entry when pressed, so their release comes after it is closed
+	for _, k := range keyNames {
+		if !ebiten.IsKeyPressed(k) {
+			delete(t.typed, k)
+			continue
+		}
+		if t.typed == nil {
+			t.typed = map[ebiten.Key]bool{}
+		}
+		t.typed[k] = true
+	}
+	switch {
+	case inpututil.IsKeyJustPressed(ebiten.KeyEnter):
+		t.active = false
+		t.done(strings.TrimSpace(t.text))
+	case inpututil.IsKeyJustPressed(ebiten.KeyEscape):
+		t.active = false
+	case inpututil.IsKeyJustPressed(ebiten.KeyBackspace) && len(t.text) > 0:
+		t.text = t.text[:len(t.text)-1]
+	}
+}
+
+// String displays the prompt followed by the text typed so far
+func (t *textEntry) String() string {
+	return t.prompt + t.text + "_"
+}
+
+// keyReleased reports whether k was just released, ignoring keys used for typing
+func keyReleased(k ebiten.Key) bool {
+	if entry.active || !inpututil.IsKeyJustReleased(k) {
+		return false
+	}
+	if entry.typed[k] {
+		delete(entry.typed, k)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,288 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten"
+)
+
+// action is something done from the keyboard
+type action struct {
+	name string   // used in the config file
+	help string   // shown in the help overlay
+	keys []string // bindings like "Shift+S", the defaults until the config is loaded
+	run  func()
+}
+
+// binding is a key and the modifiers held with it
+type binding struct {
+	key         ebiten.Key
+	shift, ctrl bool
+}
+
+var (
+	actions     []*action           // in the order they are listed in the help overlay
+	bindings    map[binding]*action // the active key map
+	helpVisible bool
+
+	// keyNames are the names keys go by in the config file
+	keyNames = map[string]ebiten.Key{
+		"0": ebiten.Key0, "1": ebiten.Key1, "2": ebiten.Key2, "3": ebiten.Key3, "4": ebiten.Key4,
+		"5": ebiten.Key5, "6": ebiten.Key6, "7": ebiten.Key7, "8": ebiten.Key8, "9": ebiten.Key9,
+		"A": ebiten.KeyA, "B": ebiten.KeyB, "C": ebiten.KeyC, "D": ebiten.KeyD, "E": ebiten.KeyE,
+		"F": ebiten.KeyF, "G": ebiten.KeyG, "H": ebiten.KeyH, "I": ebiten.KeyI, "J": ebiten.KeyJ,
+		"K": ebiten.KeyK, "L": ebiten.KeyL, "M": ebiten.KeyM, "N": ebiten.KeyN, "O": ebiten.KeyO,
+		"P": ebiten.KeyP, "Q": ebiten.KeyQ, "R": ebiten.KeyR, "S": ebiten.KeyS, "T": ebiten.KeyT,
+		"U": ebiten.KeyU, "V": ebiten.KeyV, "W": ebiten.KeyW, "X": ebiten.KeyX, "Y": ebiten.KeyY,
+		"Z":  ebiten.KeyZ,
+		"F1": ebiten.KeyF1, "F2": ebiten.KeyF2, "F3": ebiten.KeyF3, "F4": ebiten.KeyF4,
+		"F5": ebiten.KeyF5, "F6": ebiten.KeyF6, "F7": ebiten.KeyF7, "F8": ebiten.KeyF8,
+		"F9": ebiten.KeyF9, "F10": ebiten.KeyF10, "F11": ebiten.KeyF11, "F12": ebiten.KeyF12,
+		"Up": ebiten.KeyUp, "Down": ebiten.KeyDown, "Left": ebiten.KeyLeft, "Right": ebiten.KeyRight,
+		"PageUp": ebiten.KeyPageUp, "PageDown": ebiten.KeyPageDown, "Home": ebiten.KeyHome, "End": ebiten.KeyEnd,
+		"Insert": ebiten.KeyInsert, "Delete": ebiten.KeyDelete, "Backspace": ebiten.KeyBackspace,
+		"Enter": ebiten.KeyEnter, "Escape": ebiten.KeyEscape, "Tab": ebiten.KeyTab, "Space": ebiten.KeySpace,
+		"Equal": ebiten.KeyEqual, "Minus": ebiten.KeyMinus, "LeftBracket": ebiten.KeyLeftBracket,
+		"RightBracket": ebiten.KeyRightBracket, "Slash": ebiten.KeySlash, "Backslash": ebiten.KeyBackslash,
+		"Comma": ebiten.KeyComma, "Period": ebiten.KeyPeriod, "Semicolon": ebiten.KeySemicolon,
+		"Apostrophe": ebiten.KeyApostrophe, "GraveAccent": ebiten.KeyGraveAccent,
+	}
+)
+
+func init() {
+	actions = []*action{
+		{"help", "show this help", []string{"F1", "Shift+Slash"}, func() { helpVisible = !helpVisible }},
+		{"hud", "hide the status lines", []string{"F2"}, func() { hudHidden = !hudHidden }},
+		{"fullscreen", "toggle fullscreen", []string{"F"}, func() { ebiten.SetFullscreen(!ebiten.IsFullscreen()) }},
+		{"primary-left", "move the primary color left", []string{"Left"}, func() { nudge(&primary, -step*stepmod) }},
+		{"primary-right", "move the primary color right", []string{"Right"}, func() { nudge(&primary, step*stepmod) }},
+		{"brightness-up", "move the stops up", []string{"PageUp"}, func() { nudge(&brightness, -step*stepmod) }},
+		{"brightness-down", "move the stops down", []string{"PageDown"}, func() { nudge(&brightness, step*stepmod) }},
+		{"distance-up", "increase the distance", []string{"Up"}, func() { distance += step * stepmod }},
+		{"distance-down", "decrease the distance", []string{"Down"}, func() { distance -= step * stepmod }},
+		{"step-up", "increase the step", []string{"RightBracket"}, func() { step += stepmod }},
+		{"step-down", "decrease the step", []string{"LeftBracket"}, func() { step -= stepmod }},
+		{"stops-add", "add a stop", []string{"Equal"}, func() { stops++ }},
+		{"stops-remove", "remove a stop", []string{"Minus"}, func() { stops-- }},
+		{"copy", "copy", []string{"C"}, func() { copy = true }},
+		{"undo", "undo", []string{"Ctrl+Z"}, undo},
+		{"redo", "redo", []string{"Ctrl+Shift+Z"}, redo},
+		{"history", "show the history strip", []string{"Y"}, func() { historyVisible = !historyVisible }},
+		{"export", "export the palette", []string{"E"}, exportDialog},
+		{"export-colorset", "export an asset catalog", []string{"A"}, exportColorsetDialog},
+		{"open", "open a palette or session", []string{"O"}, openDialog},
+		{"clear-import", "clear the opened palette", []string{"Backspace"}, func() { importList = nil }},
+		{"extract", "extract colors from an image", []string{"I"}, func() { extractDialog(false) }},
+		{"extract-median", "extract colors by median cut", []string{"Shift+I"}, func() { extractDialog(true) }},
+		{"locate", "locate a hex color", []string{"H"}, func() { entry.start("hex: #", "", locateHex) }},
+		{"lock", "lock a stop to a color", []string{"L"}, func() { entry.start("lock stop [hex]: ", "", lockHex) }},
+		{"unlock", "unlock every stop", []string{"U"}, unlockAll},
+		{"release-guides", "release dragged guide lines", []string{"Shift+U"}, releaseGuides},
+		{"name", "name the palette", []string{"T"}, func() { entry.start("name: ", paletteTitle, setPaletteTitle) }},
+		{"name-stop", "name the selected stop", []string{"Shift+T"}, nameSelected},
+		{"surface", "use an image as the picker", []string{"S"}, surfaceDialog},
+		{"surface-reset", "restore the generated picker", []string{"Shift+S"}, func() {
+			if err := loadSurface(""); err != nil {
+				log.Println(err)
+			}
+		}},
+		{"gradient", "cycle the gradient space", []string{"G"}, func() { gradientSpace = (gradientSpace + 1) % gradientSpaces }},
+		{"colormap", "cycle the colormap type", []string{"V"}, func() { colormapType = (colormapType + 1) % colormapTypes }},
+		{"midpoint", "make the selected stop the diverging midpoint", []string{"Shift+V"}, chooseMidpoint},
+		{"snap", "cycle the retro palette to snap to", []string{"N"}, cycleSnap},
+		{"snap-file", "snap to a palette file", []string{"Shift+N"}, snapDialog},
+		{"recolor", "recolor an image with the palette", []string{"R"}, previewDialog},
+		{"recolor-close", "close the recolor preview", []string{"Shift+R"}, closePreview},
+		{"dither", "cycle the preview dithering", []string{"D"}, func() { previewDither = (previewDither + 1) % ditherModes }},
+		{"write-preview", "write the recolored image", []string{"W"}, writePreviewDialog},
+		{"lut-preview", "grade the preview with a LUT", []string{"K"}, toggleLUTPreview},
+		{"lut-mode", "cycle the LUT mapping", []string{"Shift+K"}, func() { lutMode = (lutMode + 1) % lutModes }},
+		{"lut-size", "cycle the LUT size", []string{"J"}, cycleLUTSize},
+		{"select-next", "select the next stop", []string{"Tab"}, func() { cycleSelection(1) }},
+		{"select-previous", "select the previous stop", []string{"Shift+Tab"}, func() { cycleSelection(-1) }},
+		{"deselect", "clear the stop selection", []string{"Escape"}, func() { selected = -1 }},
+		{"hue-down", "turn the selected hue back", []string{"Comma"}, func() { nudgeSelected(-editHueStep, 0, 0) }},
+		{"hue-up", "turn the selected hue on", []string{"Period"}, func() { nudgeSelected(editHueStep, 0, 0) }},
+		{"saturation-down", "desaturate the selected stop", []string{"Shift+Comma"}, func() { nudgeSelected(0, -editStep, 0) }},
+		{"saturation-up", "saturate the selected stop", []string{"Shift+Period"}, func() { nudgeSelected(0, editStep, 0) }},
+		{"lightness-down", "darken the selected stop", []string{"Semicolon"}, func() { nudgeSelected(0, 0, -editStep) }},
+		{"lightness-up", "lighten the selected stop", []string{"Apostrophe"}, func() { nudgeSelected(0, 0, editStep) }},
+		{"lock-selected", "lock the selected stop", []string{"Shift+L"}, lockSelected},
+		{"reset-selected", "reset the selected stop", []string{"Delete"}, resetSelected},
+		{"random", "generate a random palette", []string{"X"}, newRandom},
+		{"random-seed", "generate the palette of a seed", []string{"Shift+X"}, func() { entry.start("seed: ", "", randomSeedEntry) }},
+		{"random-constraints", "constrain random palettes", []string{"Ctrl+X"}, func() {
+			entry.start("min dE, lightness band, stops, mode: ", randomConstraints(), randomConstraintsEntry)
+		}},
+		{"bookmark", "bookmark the palette", []string{"B"}, bookmark},
+		{"library", "show the library", []string{"Shift+B"}, toggleLibrary},
+		{"search", "search the library", []string{"Slash"}, func() {
+			if libraryVisible {
+				searchLibrary()
+			}
+		}},
+	}
+	// number keys select the first ten stops
+	for n := 1; n <= 10; n++ {
+		i := n - 1
+		actions = append(actions, &action{fmt.Sprintf("select-%d", n), fmt.Sprintf("select stop %d", n), []string{fmt.Sprint(n % 10)}, func() { selectStop(i) }})
+	}
+	bindKeys()
+}
+
+// nudge moves a picker position from the keyboard unless the mouse is dragging it
+func nudge(v *int, d int) {
+	if !dragging {
+		*v += d
+	}
+}
+
+// keysPath is where the key map is configured, under the XDG config directory
+func keysPath() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, strings.ToLower(windowTitle), "keys.json")
+}
+
+// readKeys replaces the default keys of the actions named in a JSON config file,
+// for example {"primary-left": ["Left", "Shift+H"], "locate": ["Slash"]},
+// a configured key is taken from any default action that used it
+func readKeys(filename string) error {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	var config map[string][]string
+	if err := json.Unmarshal(data, &config); err != nil {
+		return fmt.Errorf("%s: %v", filename, err)
+	}
+	for name := range config {
+		if findAction(name) == nil {
+			return fmt.Errorf("%s: unknown action %q", filename, name)
+		}
+	}
+	configured := map[binding]bool{}
+	for name, keys := range config {
+		for _, k := range keys {
+			b, err := parseBinding(k)
+			if err != nil {
+				return fmt.Errorf("%s: %s: %v", filename, name, err)
+			}
+			configured[b] = true
+		}
+	}
+	for _, a := range actions {
+		if keys, ok := config[a.name]; ok {
+			a.keys = keys
+			continue
+		}
+		var kept []string
+		for _, k := range a.keys {
+			if b, err := parseBinding(k); err == nil && configured[b] {
+				continue
+			}
+			kept = append(kept, k)
+		}
+		a.keys = kept
+	}
+	bindKeys()
+	return nil
+}
+
+// findAction returns the action with the given name
+func findAction(name string) *action {
+	for _, a := range actions {
+		if a.name == name {
+			return a
+		}
+	}
+	return nil
+}
+
+// bindKeys builds the key map from the keys of every action
+func bindKeys() {
+	bindings = map[binding]*action{}
+	for _, a := range actions {
+		for _, k := range a.keys {
+			b, err := parseBinding(k)
+			if err != nil {
+				log.Println(a.name+":", err)
+				continue
+			}
+			if other, ok := bindings[b]; ok {
+				log.Printf("%s is bound to both %s and %s", k, other.name, a.name)
+			}
+			bindings[b] = a
+		}
+	}
+}
+
+// parseBinding reads a key name with optional Shift+ and Ctrl+ modifiers
+func parseBinding(s string) (binding, error) {
+	var b binding
+	parts := strings.Split(s, "+")
+	for _, m := range parts[:len(parts)-1] {
+		switch strings.ToLower(strings.TrimSpace(m)) {
+		case "shift":
+			b.shift = true
+		case "ctrl", "control":
+			b.ctrl = true
+		default:
+			return b, fmt.Errorf("unknown modifier %q in %q", m, s)
+		}
+	}
+	name := strings.TrimSpace(parts[len(parts)-1])
+	for n, k := range keyNames {
+		if strings.EqualFold(n, name) {
+			b.key = k
+			return b, nil
+		}
+	}
+	return b, fmt.Errorf("unknown key %q", s)
+}
+
+// runActions runs the actions bound to the keys released this frame, in the order they are listed.
+// A key held with modifiers it has no binding for falls back to the binding without them,
+// so ctrl can scale the step of the plain bindings
+func runActions() {
+	shift := ebiten.IsKeyPressed(ebiten.KeyShift)
+	ctrl := ebiten.IsKeyPressed(ebiten.KeyControl)
+	fired := map[*action]bool{}
+	for _, k := range keyNames {
+		if !keyReleased(k) {
+			continue
+		}
+		for _, b := range []binding{{k, shift, ctrl}, {k, shift, false}, {k, false, ctrl}, {k, false, false}} {
+			if a, ok := bindings[b]; ok {
+				fired[a] = true
+				break
+			}
+		}
+	}
+	for _, a := range actions {
+		if fired[a] {
+			a.run()
+		}
+	}
+}
+
+// helpLines lists every action with the keys it is bound to
+func helpLines() []string {
+	lines := make([]string, 0, len(actions)+1)
+	for _, a := range actions {
+		lines = append(lines, fmt.Sprintf("%-22s %s", strings.Join(a.keys, ", "), a.help))
+	}
+	return append(lines, fmt.Sprintf("%-22s %s", "Ctrl+any step key", "move 10 times the step"))
+}
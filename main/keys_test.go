@@ -0,0 +1,91 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten"
+)
+
+// saveKeys returns a function restoring the keys of every action
+func saveKeys() func() {
+	keys := make([][]string, len(actions))
+	for i, a := range actions {
+		keys[i] = a.keys
+	}
+	return func() {
+		for i, a := range actions {
+			a.keys = keys[i]
+		}
+		bindKeys()
+	}
+}
+
+func TestParseBinding(t *testing.T) {
+	tests := []struct {
+		in   string
+		want binding
+		err  bool
+	}{
+		{"Left", binding{key: ebiten.KeyLeft}, false},
+		{"Shift+H", binding{key: ebiten.KeyH, shift: true}, false},
+		{"ctrl+shift+slash", binding{key: ebiten.KeySlash, shift: true, ctrl: true}, false},
+		{"Control + 0", binding{key: ebiten.Key0, ctrl: true}, false},
+		{"Alt+H", binding{}, true},
+		{"Nope", binding{}, true},
+	}
+	for _, test := range tests {
+		got, err := parseBinding(test.in)
+		if (err != nil) != test.err {
+			t.Errorf("%q: error %v", test.in, err)
+			continue
+		}
+		if !test.err && got != test.want {
+			t.Errorf("%q: got %+v, want %+v", test.in, got, test.want)
+		}
+	}
+}
+
+func TestReadKeysTakesDefault(t *testing.T) {
+	defer saveKeys()()
+	dir, err := ioutil.TempDir("", "keys")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "keys.json")
+	config := `{"primary-left": ["Left", "Shift+H"], "locate": ["Slash"]}`
+	if err := ioutil.WriteFile(filename, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := readKeys(filename); err != nil {
+		t.Fatal(err)
+	}
+	if a := bindings[binding{key: ebiten.KeySlash}]; a == nil || a.name != "locate" {
+		t.Errorf("Slash is bound to %v, want locate", a)
+	}
+	if a := bindings[binding{key: ebiten.KeyH, shift: true}]; a == nil || a.name != "primary-left" {
+		t.Errorf("Shift+H is bound to %v, want primary-left", a)
+	}
+	if keys := findAction("search").keys; len(keys) != 0 {
+		t.Errorf("search kept %v", keys)
+	}
+}
+
+func TestReadKeysUnknownAction(t *testing.T) {
+	defer saveKeys()()
+	dir, err := ioutil.TempDir("", "keys")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "keys.json")
+	if err := ioutil.WriteFile(filename, []byte(`{"fly": ["F"]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := readKeys(filename); err == nil {
+		t.Error("expected an error for an unknown action")
+	}
+}
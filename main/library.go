@@ -0,0 +1,216 @@
+package main
+
+import (
+	"encoding/json"
+	"image"
+	"image/color"
+	"io/ioutil"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/ebitenutil"
+)
+
+// libraryEntry is a bookmarked palette
+type libraryEntry struct {
+	Name    string   `json:"name"`
+	Tags    []string `json:"tags,omitempty"`
+	Colors  []string `json:"colors"`
+	Session session  `json:"session"`
+}
+
+var (
+	library        []libraryEntry
+	libraryLoaded  bool
+	libraryVisible bool
+	libraryFilter  string // tag or name words, or a #hex color to look for
+	libraryNear    = 10.0 // ΔE2000 within which a palette counts as containing a color
+	libraryScroll  int    // first row of the grid shown
+
+	libraryCellW = 180
+	libraryCellH = 60
+	libraryGap   = 10
+)
+
+// libraryPath is where bookmarks are kept, under the XDG data directory
+func libraryPath() string {
+	dir := os.Getenv("XDG_DATA_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dir, strings.ToLower(windowTitle), "library.json")
+}
+
+// loadLibrary reads the bookmarks the first time they are needed
+func loadLibrary() {
+	if libraryLoaded {
+		return
+	}
+	libraryLoaded = true
+	data, err := ioutil.ReadFile(libraryPath())
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Println(err)
+		}
+		return
+	}
+	if err := json.Unmarshal(data, &library); err != nil {
+		log.Println(err)
+	}
+}
+
+// saveLibrary writes the bookmarks
+func saveLibrary() error {
+	data, err := json.MarshalIndent(library, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(libraryPath()), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(libraryPath(), data, 0644)
+}
+
+// bookmark adds the current palette to the library, then asks for its tags
+func bookmark() {
+	loadLibrary()
+	e := libraryEntry{Name: paletteName(), Session: currentSession()}
+	for _, c := range paletteColors() {
+		e.Colors = append(e.Colors, hexString(c))
+	}
+	library = append(library, e)
+	i := len(library) - 1
+	entry.start("tags: ", "", func(s string) {
+		library[i].Tags = strings.Fields(strings.ToLower(strings.Replace(s, ",", " ", -1)))
+		if err := saveLibrary(); err != nil {
+			log.Println(err)
+		}
+	})
+	// keep the bookmark even if tagging is cancelled
+	if err := saveLibrary(); err != nil {
+		log.Println(err)
+	}
+}
+
+// toggleLibrary shows or hides the library grid
+func toggleLibrary() {
+	loadLibrary()
+	libraryVisible = !libraryVisible
+	libraryScroll = 0
+}
+
+// searchLibrary asks for the filter applied to the grid
+func searchLibrary() {
+	entry.start("search: ", libraryFilter, func(s string) {
+		libraryFilter = s
+		libraryScroll = 0
+	})
+}
+
+// matches reports whether the entry passes the filter
+func (e libraryEntry) matches(filter string) bool {
+	if filter == "" {
+		return true
+	}
+	// a hex color matches palettes containing something close to it
+	if strings.HasPrefix(filter, "#") {
+		c, err := parseHex(filter)
+		if err != nil {
+			return false
+		}
+		target := rgbToLab(c)
+		for _, h := range e.Colors {
+			if p, err := parseHex(h); err == nil && deltaE2000(target, rgbToLab(p)) <= libraryNear {
+				return true
+			}
+		}
+		return false
+	}
+	// every word has to match a tag or appear in the name
+	text := strings.ToLower(e.Name + " " + strings.Join(e.Tags, " "))
+	for _, word := range strings.Fields(strings.ToLower(filter)) {
+		if !strings.Contains(text, word) {
+			return false
+		}
+	}
+	return true
+}
+
+// libraryMatches lists the indexes of entries passing the filter
+func libraryMatches() []int {
+	var list []int
+	for i, e := range library {
+		if e.matches(libraryFilter) {
+			list = append(list, i)
+		}
+	}
+	return list
+}
+
+// libraryColumns is the number of cells in each grid row
+func libraryColumns() int {
+	return (screenW - padding) / (libraryCellW + libraryGap)
+}
+
+// libraryCellRect is the screen area of the nth visible cell
+func libraryCellRect(n int) image.Rectangle {
+	row, col := n/libraryColumns()-libraryScroll, n%libraryColumns()
+	x := padding + col*(libraryCellW+libraryGap)
+	y := padding*3 + row*(libraryCellH+libraryGap)
+	return image.Rect(x, y, x+libraryCellW, y+libraryCellH)
+}
+
+// scrollLibrary moves the grid by the wheel delta
+func scrollLibrary(delta float64) {
+	libraryScroll -= int(math.Round(delta))
+	rows := (len(libraryMatches()) + libraryColumns() - 1) / libraryColumns()
+	if libraryScroll > rows-1 {
+		libraryScroll = rows - 1
+	}
+	if libraryScroll < 0 {
+		libraryScroll = 0
+	}
+}
+
+// clickLibrary loads the entry under the cursor and closes the grid
+func clickLibrary(cursor image.Point) {
+	for n, i := range libraryMatches() {
+		if cursor.In(libraryCellRect(n)) {
+			if err := library[i].Session.apply(); err != nil {
+				log.Println(err)
+			}
+			libraryVisible = false
+			return
+		}
+	}
+}
+
+// drawLibrary draws the grid of saved palettes over the whole window
+func drawLibrary(screen *ebiten.Image) {
+	if !libraryVisible {
+		return
+	}
+	screen.Fill(color.RGBA{0x22, 0x22, 0x22, 0xff})
+	ebitenutil.DebugPrintAt(screen, "library: "+libraryFilter, padding, padding)
+	for n, i := range libraryMatches() {
+		r := libraryCellRect(n)
+		if r.Max.Y < 0 || r.Min.Y > screenH {
+			continue
+		}
+		e := library[i]
+		w := float64(r.Dx()) / float64(len(e.Colors))
+		for j, h := range e.Colors {
+			c, _ := parseHex(h)
+			ebitenutil.DrawRect(screen, float64(r.Min.X)+float64(j)*w, float64(r.Min.Y), math.Ceil(w), float64(r.Dy()-32), c)
+		}
+		ebitenutil.DebugPrintAt(screen, e.Name+"\n"+strings.Join(e.Tags, " "), r.Min.X, r.Max.Y-32)
+	}
+}
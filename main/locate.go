@@ -0,0 +1,35 @@
+package main
+
+import (
+	"image/color"
+	"log"
+)
+
+// locateDE is the ΔE2000 between the last located color and the picker
+var locateDE float64
+
+// locateHex parses a typed hex value and seeds the generator from it
+func locateHex(s string) {
+	c, err := parseHex(s)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	locateColor(c)
+}
+
+// locateColor moves the primary stop to the picker position closest to c
+// so the golden stops grow from it, solving the generated gradient before checking the image
+func locateColor(c color.RGBA) {
+	var x, y int
+	if surfaceFile == "" {
+		x, y, _ = solveSurface(c)
+		x, y, locateDE = refinePickerPoint(c, x, y)
+	} else {
+		x, y, locateDE = nearestPickerPoint(c)
+	}
+	importList = nil
+	primary = x
+	brightness = y
+	clampParams()
+}
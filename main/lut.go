@@ -0,0 +1,167 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"sort"
+	"strings"
+)
+
+// LUT mapping modes
+const (
+	lutGradientMap = iota // map input luminance along the palette sorted by lightness
+	lutSoftNearest        // pull input toward nearby stops, weighted by distance
+	lutModes
+)
+
+var (
+	lutSize     = 33
+	lutSizes    = []int{17, 33, 65}
+	lutMode     = lutGradientMap
+	lutNames    = []string{"gradient map", "soft nearest"}
+	lutSoftness = 20.0 // ΔE at which a stop's pull has fallen off noticeably
+
+	// previewLUT grades the preview with the LUT instead of quantizing it
+	previewLUT bool
+
+	lutCache    [][3]float64 // last LUT built, reused until the palette, mode or size changes
+	lutCacheKey string
+)
+
+// cachedLUT returns the LUT for the palette, only building it when something has changed
+func cachedLUT(size int) [][3]float64 {
+	key := fmt.Sprint(paletteKey(), lutMode, size)
+	if key != lutCacheKey {
+		lutCache, lutCacheKey = buildLUT(size), key
+	}
+	return lutCache
+}
+
+// cycleLUTSize moves to the next LUT grid size
+func cycleLUTSize() {
+	for i, size := range lutSizes {
+		if size == lutSize {
+			lutSize = lutSizes[(i+1)%len(lutSizes)]
+			return
+		}
+	}
+	lutSize = lutSizes[0]
+}
+
+// toggleLUTPreview grades the preview image with the LUT, using the picker as a test image
+// when no image has been opened
+func toggleLUTPreview() {
+	if previewLUT && previewSrc == pickerImg {
+		closePreview()
+		return
+	}
+	previewLUT = !previewLUT
+	if previewLUT && previewSrc == nil {
+		setPreview(pickerImg)
+	}
+	previewKey = ""
+}
+
+// lutMapper builds the function mapping input colors toward the palette
+func lutMapper() func(r, g, b float64) labColor {
+	palette := paletteColors()
+	labs := make([]labColor, len(palette))
+	for i, c := range palette {
+		labs[i] = rgbToLab(c)
+	}
+	input := unitToLab
+
+	if lutMode == lutSoftNearest {
+		return func(r, g, b float64) labColor {
+			in := input(r, g, b)
+			var out labColor
+			total := 0.0
+			for _, l := range labs {
+				d := deltaE76(in, l)
+				w := math.Exp(-d * d / (2 * lutSoftness * lutSoftness))
+				out.l, out.a, out.b = out.l+l.l*w, out.a+l.a*w, out.b+l.b*w
+				total += w
+			}
+			// colors far from every stop keep their own value
+			keep := math.Max(0, 1-total)
+			out.l, out.a, out.b = out.l+in.l*keep, out.a+in.a*keep, out.b+in.b*keep
+			total += keep
+			return labColor{out.l / total, out.a / total, out.b / total}
+		}
+	}
+
+	// gradient map, darkest stop for black through lightest stop for white
+	sort.Slice(labs, func(i, j int) bool { return labs[i].l < labs[j].l })
+	return func(r, g, b float64) labColor {
+		f := input(r, g, b).l / 100 * float64(len(labs)-1)
+		i := int(f)
+		if i >= len(labs)-1 {
+			return labs[len(labs)-1]
+		}
+		t := f - float64(i)
+		p, q := labs[i], labs[i+1]
+		return labColor{p.l + (q.l-p.l)*t, p.a + (q.a-p.a)*t, p.b + (q.b-p.b)*t}
+	}
+}
+
+// buildLUT samples the mapping on a size³ grid, red changing fastest as in .cube files,
+// keeping 0-1 channels so the .cube output isn't limited to 8 bits
+func buildLUT(size int) [][3]float64 {
+	mapper := lutMapper()
+	lut := make([][3]float64, 0, size*size*size)
+	n := float64(size - 1)
+	for b := 0; b < size; b++ {
+		for g := 0; g < size; g++ {
+			for r := 0; r < size; r++ {
+				lut = append(lut, labToUnit(mapper(float64(r)/n, float64(g)/n, float64(b)/n)))
+			}
+		}
+	}
+	return lut
+}
+
+// applyLUT grades src with trilinear interpolation of the LUT
+func applyLUT(src image.Image, lut [][3]float64, size int) *image.RGBA {
+	b := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	n := float64(size - 1)
+	at := func(r, g, b int) [3]float64 {
+		return lut[(b*size+g)*size+r]
+	}
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			c := toRGBA(src.At(b.Min.X+x, b.Min.Y+y))
+			fr, fg, fb := float64(c.R)/255*n, float64(c.G)/255*n, float64(c.B)/255*n
+			r0, g0, b0 := int(math.Min(fr, n-1)), int(math.Min(fg, n-1)), int(math.Min(fb, n-1))
+			tr, tg, tb := fr-float64(r0), fg-float64(g0), fb-float64(b0)
+			var out [3]float64
+			for ch := range out {
+				lerp := func(p, q [3]float64, t float64) float64 {
+					return p[ch] + (q[ch]-p[ch])*t
+				}
+				c00 := lerp(at(r0, g0, b0), at(r0+1, g0, b0), tr)
+				c10 := lerp(at(r0, g0+1, b0), at(r0+1, g0+1, b0), tr)
+				c01 := lerp(at(r0, g0, b0+1), at(r0+1, g0, b0+1), tr)
+				c11 := lerp(at(r0, g0+1, b0+1), at(r0+1, g0+1, b0+1), tr)
+				c0 := c00 + (c10-c00)*tg
+				c1 := c01 + (c11-c01)*tg
+				out[ch] = c0 + (c1-c0)*tb
+			}
+			dst.SetRGBA(x, y, color.RGBA{unitToByte(out[0]), unitToByte(out[1]), unitToByte(out[2]), 255})
+		}
+	}
+	return dst
+}
+
+// exportCube generates an Adobe/Resolve 3D LUT
+func exportCube() []byte {
+	var output strings.Builder
+	fmt.Fprintf(&output, "TITLE \"%s\"\nLUT_3D_SIZE %d\nDOMAIN_MIN 0.0 0.0 0.0\nDOMAIN_MAX 1.0 1.0 1.0\n", plainName(paletteName()), lutSize)
+	// the 65 point grid has over a quarter million lines, so build the output in one buffer
+	for _, c := range cachedLUT(lutSize) {
+		fmt.Fprintf(&output, "%.6f %.6f %.6f\n", c[0], c[1], c[2])
+	}
+	return []byte(output.String())
+}
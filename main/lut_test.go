@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestBuildLUT(t *testing.T) {
+	setTestStops([]color.RGBA{{20, 30, 90, 255}, {200, 60, 40, 255}, {240, 230, 200, 255}})
+	defer func(mode int) { lutMode = mode }(lutMode)
+	for mode := 0; mode < lutModes; mode++ {
+		lutMode = mode
+		for _, size := range lutSizes {
+			lut := buildLUT(size)
+			if len(lut) != size*size*size {
+				t.Errorf("%s %d: %d entries, want %d", lutNames[mode], size, len(lut), size*size*size)
+				continue
+			}
+			for _, c := range lut {
+				for _, v := range c {
+					if v < 0 || v > 1 || math.IsNaN(v) {
+						t.Fatalf("%s %d: entry %v out of range", lutNames[mode], size, c)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestLUTGradientMapEnds(t *testing.T) {
+	dark, light := color.RGBA{20, 30, 90, 255}, color.RGBA{240, 230, 200, 255}
+	setTestStops([]color.RGBA{light, {200, 60, 40, 255}, dark})
+	defer func(mode int) { lutMode = mode }(lutMode)
+	lutMode = lutGradientMap
+	size := lutSizes[0]
+	lut := buildLUT(size)
+	tests := []struct {
+		name  string
+		entry [3]float64
+		want  color.RGBA
+	}{
+		{"black", lut[0], dark},
+		{"white", lut[len(lut)-1], light},
+	}
+	for _, test := range tests {
+		got := color.RGBA{unitToByte(test.entry[0]), unitToByte(test.entry[1]), unitToByte(test.entry[2]), 255}
+		if got != test.want {
+			t.Errorf("%s maps to %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestExportCubePrecision(t *testing.T) {
+	setTestStops([]color.RGBA{{20, 30, 90, 255}, {200, 60, 40, 255}, {240, 230, 200, 255}})
+	defer func(mode int) { lutMode = mode }(lutMode)
+	lutMode = lutSoftNearest
+	lutCacheKey = ""
+	scanner := bufio.NewScanner(bytes.NewReader(exportCube()))
+	lines, between := 0, 0
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 3 {
+			continue
+		}
+		v, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			continue
+		}
+		lines++
+		// 8-bit values would all land within rounding of a multiple of 1/255
+		if d := math.Abs(v*255 - math.Round(v*255)); d > 0.01 {
+			between++
+		}
+	}
+	if lines != lutSize*lutSize*lutSize {
+		t.Errorf("%d entries, want %d", lines, lutSize*lutSize*lutSize)
+	}
+	if between == 0 {
+		t.Error("every entry is quantized to 8 bits")
+	}
+}
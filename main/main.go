@@ -1,15 +1,16 @@
 package main
 
 import (
-	"bytes"
+	"flag"
 	"fmt"
 	"image"
 	"image/color"
 	"io/ioutil"
 	"log"
 	"math"
-
-	resources "github.com/jeffchannell/phibar/main/resources/images"
+	"os"
+	"sort"
+	"strings"
 
 	"github.com/golang/freetype/truetype"
 	"github.com/hajimehoshi/ebiten"
@@ -25,8 +26,13 @@ type colorStop struct {
 	color      color.Color // the color
 	val        float64     // stop value, different from offset (for calculating the others)
 	off        float64     // x or y offset
+	py         int         // picker y the color comes from
+	orig       color.RGBA  // color before snapping to a target palette
 	c, m, y, k uint8       // CMYK colors
 	r, g, b    uint8       // RGB colors
+	hue        float64     // HSB hue in degrees
+	sat, bri   float64     // HSB saturation and brightness
+	edit       stopEdit    // hand edits applied over the generated color
 }
 
 // cmyk generates the display string for CMYK colors
@@ -39,6 +45,20 @@ func (s *colorStop) hex() string {
 	return fmt.Sprintf("#%02X%02X%02X", s.r, s.g, s.b)
 }
 
+// dark generates the dark appearance variant of this stop
+func (s *colorStop) dark() color.Color {
+	if colorsetNegative {
+		return s.negative()
+	}
+	h, sat, l := rgbToHSL(s.r, s.g, s.b)
+	return hslToRGB(h, sat, 1-l)
+}
+
+// hsb generates the display string for HSB colors
+func (s *colorStop) hsb() string {
+	return fmt.Sprintf("hsb(%.0f, %.0f%%, %.0f%%)", s.hue, s.sat*100, s.bri*100)
+}
+
 // negative color from this stop
 func (s *colorStop) negative() color.Color {
 	return color.RGBA{255 - s.r, 255 - s.g, 255 - s.b, 255}
@@ -53,8 +73,17 @@ func (s *colorStop) rgb() string {
 func (s *colorStop) setColor(c color.Color) {
 	s.color = c
 	r, g, b, _ := c.RGBA()
-	s.r, s.g, s.b = uint8(r), uint8(g), uint8(b)
+	s.r, s.g, s.b = uint8(r>>8), uint8(g>>8), uint8(b>>8)
 	s.c, s.m, s.y, s.k = color.RGBToCMYK(s.r, s.g, s.b)
+	s.hue, s.sat, s.bri = rgbToHSB(s.r, s.g, s.b)
+}
+
+// snap replaces the color with the closest color of the target palette, remembering the original
+func (s *colorStop) snap() {
+	s.orig = color.RGBA{s.r, s.g, s.b, 255}
+	if snapTarget != nil {
+		s.setColor(snapColor(s.orig))
+	}
 }
 
 func (s *colorStop) setVal(val float64) {
@@ -75,10 +104,11 @@ func (s *colorStop) setVal(val float64) {
 var (
 	windowTitle = "PhiBar"
 
-	picker   *ebiten.Image // color picker image
-	copy     bool
-	dragging bool
-	ctrlDown bool // ctrl button is down
+	picker    *ebiten.Image // color picker image
+	pickerImg image.Image   // color picker source pixels, sampled for the stop colors
+	copy      bool
+	dragging  bool
+	ctrlDown  bool // ctrl button is down
 
 	outputH = 300
 	padding = 20
@@ -95,6 +125,7 @@ var (
 	stepmax    = 50
 	stepmin    = 1
 	stepmod    = 1
+	ratio      = 1.0 // scales the golden step between stops, back-solved by shift dragging a guide line
 	stopmax    = 16
 	stopmin    = 3
 	stops      = 3
@@ -108,8 +139,61 @@ var (
 	fontSize float64 = 16
 )
 
+// exporters map file extensions to the function generating their data
+var exporters = map[string]func() []byte{
+	".ase":          exportASE,
+	".css":          exportCSS,
+	".cube":         exportCube,
+	".ggr":          exportGGR,
+	".gradient.svg": exportGradientSVG,
+	".go":           exportGo,
+	".png":          exportSheetPNG,
+	".py":           exportMatplotlib,
+	".svg":          exportSheetSVG,
+	".gpl":          exportGPL,
+	".hex":          exportHex,
+	".json":         exportVega,
+	".lut":          exportLUT,
+	".kpl":          exportKPL,
+	".xml":          exportAndroid,
+	".pal":          exportPAL,
+	".phibar":       exportSession,
+	".swatches":     exportSwatches,
+	".soc":          exportSOC,
+	".txt":          exportPaintNET,
+}
+
+// exportFilter builds the file dialog filter from the supported extensions
+func exportFilter() string {
+	exts := make([]string, 0, len(exporters))
+	for ext := range exporters {
+		exts = append(exts, ext)
+	}
+	return extFilter(exts)
+}
+
+// extFilter builds a sorted file dialog filter matching the extensions
+func extFilter(exts []string) string {
+	patterns := make([]string, 0, len(exts))
+	for _, ext := range exts {
+		patterns = append(patterns, "*"+ext)
+	}
+	sort.Strings(patterns)
+	return strings.Join(patterns, " ")
+}
+
+// stopName generates the label used for the stop at index i in exports
+func stopName(i int) string {
+	return fmt.Sprintf("Index%d", i)
+}
+
+// activeStops returns the stops currently in use
+func activeStops() []colorStop {
+	return stoplist[:stops]
+}
+
 func exportGPL() []byte {
-	output := "GIMP Palette\nName: PhiBar\nColumns: 4\n#"
+	output := fmt.Sprintf("GIMP Palette\nName: %s\nColumns: 4\n#", paletteName())
 	for i := range stoplist {
 		if i == stops {
 			break
@@ -120,7 +204,7 @@ func exportGPL() []byte {
 			g = stoplist[i].g
 			b = stoplist[i].b
 		}
-		output = fmt.Sprintf("%s\n%d %d %d Index%d", output, r, g, b, i)
+		output = fmt.Sprintf("%s\n%d %d %d %s", output, r, g, b, stopLabel(i))
 	}
 	return []byte(output)
 }
@@ -141,6 +225,102 @@ func exportPAL() []byte {
 	return []byte(output)
 }
 
+// exporterFor finds the exporter for the filename, the longest matching extension wins
+// so names like palette.gradient.svg pick the gradient over the swatch sheet
+func exporterFor(filename string) (func() []byte, bool) {
+	lower, ext := strings.ToLower(filename), ""
+	for e := range exporters {
+		if strings.HasSuffix(lower, e) && len(e) > len(ext) {
+			ext = e
+		}
+	}
+	export, ok := exporters[ext]
+	return export, ok
+}
+
+// writeExport saves the palette, the file extension determines which type of file to export
+func writeExport(filename string) error {
+	export, ok := exporterFor(filename)
+	if !ok {
+		export = exportGPL
+		filename += ".gpl"
+	}
+	return ioutil.WriteFile(filename, export(), 0644)
+}
+
+// clampParams keeps the generator parameters within bounds
+func clampParams() {
+	if stops > stopmax {
+		stops = stopmax
+	} else if stops < stopmin {
+		stops = stopmin
+	}
+	if step > stepmax {
+		step = stepmax
+	} else if step < stepmin {
+		step = stepmin
+	}
+	if distance > screenW {
+		distance = screenW
+	} else if distance < -screenW {
+		distance = -screenW
+	}
+	if brightness < 0 {
+		brightness = 0
+	} else if brightness >= pickerH {
+		brightness = pickerH - 1
+	}
+	if primary < 0 {
+		primary += screenW
+	} else if primary > screenW {
+		primary -= screenW
+	}
+}
+
+// updateStops calculates the value and color of every active stop
+func updateStops() {
+	for i := range stoplist {
+		if i == stops {
+			break
+		}
+		// imported colors are shown as-is in place of the generated stops
+		if i < len(importList) {
+			stoplist[i].setVal(float64(importList[i].x))
+			stoplist[i].py = importList[i].y
+			stoplist[i].setColor(importList[i].color)
+			stoplist[i].applyEdit()
+			stoplist[i].snap()
+			continue
+		}
+		switch i {
+		case 0:
+			stoplist[i].setVal(float64(primary))
+		case 1:
+			stoplist[i].setVal(float64(primary + distance))
+		default:
+			stoplist[i].setVal(nextStop(stoplist[i-2].val, stoplist[i-1].val, ratio))
+			// dragged guide lines break the sequence
+			if v, ok := overrides[i]; ok {
+				stoplist[i].setVal(v)
+			}
+		}
+		// colors come from the picker itself so the drawing can't pull colors from the guides
+		stoplist[i].py = brightness
+		stoplist[i].setColor(pickerImg.At(int(math.Round(stoplist[i].off)), stoplist[i].py))
+		// anchored stops keep their locked color
+		if c, ok := anchors[i]; ok {
+			stoplist[i].setColor(c)
+		}
+		stoplist[i].applyEdit()
+		stoplist[i].snap()
+	}
+}
+
+// nextStop is the position after the stops at a and b, the golden step scaled by the ratio r
+func nextStop(a, b, r float64) float64 {
+	return b + (golden.Next(a, b)-b)*r
+}
+
 func init() {
 	stoplist = make([]colorStop, stopmax)
 }
@@ -156,79 +336,199 @@ func main() {
 		DPI:     dpi,
 		Hinting: font.HintingFull,
 	})
+	helpFont = truetype.NewFace(tt, &truetype.Options{
+		Size:    fontSize / 2,
+		DPI:     dpi,
+		Hinting: font.HintingFull,
+	})
 
-	img, _, err := image.Decode(bytes.NewReader(resources.Palette_png))
-	if err != nil {
+	if err := loadSurface(""); err != nil {
 		log.Fatal(err)
 	}
-	picker, _ = ebiten.NewImageFromImage(img, ebiten.FilterDefault)
+
+	// command line options allow exporting without opening a window
+	exportFile := flag.String("export", "", "write the palette to this file and exit without opening a window")
+	flag.IntVar(&primary, "primary", primary, "x position of the primary color")
+	flag.IntVar(&distance, "distance", distance, "distance between the first two stops")
+	flag.IntVar(&brightness, "brightness", brightness, "y position of the stops")
+	flag.IntVar(&stops, "stops", stops, "number of stops")
+	flag.IntVar(&sheetShades, "shades", sheetShades, "number of tints and shades in swatch sheets")
+	flag.BoolVar(&colorsetNegative, "negative", colorsetNegative, "use the negative color for the dark appearance of Xcode colorsets")
+	flag.Var(surfaceValue{}, "surface", "image to use as the picker instead of the generated gradient")
+	flag.BoolVar(&debugHUD, "debug", false, "show frame rates and the cursor position")
+	seed := flag.Int64("seed", -1, "generate a random palette from this seed")
+	flag.Var(randomValue{}, "random", `constraints on random palettes: a minimum ΔE2000 between stops, a lightness band like 30-70, "stops" and "mode"`)
+	flag.Var(colormapValue{}, "colormap", "arrange the stops as an off, sequential, diverging or cyclic colormap")
+	flag.IntVar(&colormapMid, "midpoint", colormapMid, "stop a diverging colormap meets at, the lightest when not set")
+	flag.Parse()
+
+	if *exportFile != "" {
+		if *seed >= 0 {
+			randomize(*seed)
+		}
+		clampParams()
+		updateStops()
+		if err := writeExport(*exportFile); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	// restore the last session, command line options still take priority
+	explicit := map[string]string{}
+	flag.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = f.Value.String()
+	})
+	if err := readSession(sessionPath()); err != nil && !os.IsNotExist(err) {
+		log.Println(err)
+	}
+	flag.Visit(func(f *flag.Flag) {
+		if err := f.Value.Set(explicit[f.Name]); err != nil {
+			log.Println(err)
+		}
+	})
+	if *seed >= 0 {
+		randomize(*seed)
+	}
+
+	// remapped keys
+	if err := readKeys(keysPath()); err != nil && !os.IsNotExist(err) {
+		log.Println(err)
+	}
 
 	if err := ebiten.Run(update, screenW, screenH, 1, windowTitle); err != nil {
 		panic(err)
 	}
+
+	// the window has closed, keep the session for next time
+	if err := writeSession(sessionPath()); err != nil {
+		log.Println(err)
+	}
+}
+
+// exportDialog asks where to export the palette
+func exportDialog() {
+	filename, success, err := FileNamed("Select file", exportFilter(), paletteFilename(".gpl"))
+	if success && (err == nil) {
+		if err := writeExport(filename); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// exportColorsetDialog asks for a directory to export an asset catalog to
+func exportColorsetDialog() {
+	dirname, success, err := File("Select directory", "", true)
+	if success && (err == nil) {
+		if err := exportColorset(dirname); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// openDialog asks for a palette or session to open
+func openDialog() {
+	filename, success, err := FileOpen("Select file", openFilter(), false)
+	if success && (err == nil) {
+		if err := readOpen(filename); err != nil {
+			log.Println(err)
+		}
+	}
+}
+
+// extractDialog asks for an image to extract the dominant colors of
+func extractDialog(medianCut bool) {
+	filename, success, err := FileOpen("Select image", imageFilter, false)
+	if success && (err == nil) {
+		if err := readExtract(filename, medianCut); err != nil {
+			log.Println(err)
+		}
+	}
+}
+
+// surfaceDialog asks for an image to use as the picker surface
+func surfaceDialog() {
+	filename, success, err := FileOpen("Select image", imageFilter, false)
+	if success && (err == nil) {
+		if err := loadSurface(filename); err != nil {
+			log.Println(err)
+		}
+	}
+}
+
+// snapDialog asks for a palette file to snap the stops to
+func snapDialog() {
+	filename, success, err := FileOpen("Select file", importFilter(), false)
+	if success && (err == nil) {
+		if err := readSnapTarget(filename); err != nil {
+			log.Println(err)
+		}
+	}
+}
+
+// previewDialog asks for an image to recolor with the palette
+func previewDialog() {
+	filename, success, err := FileOpen("Select image", imageFilter, false)
+	if success && (err == nil) {
+		if err := readPreview(filename); err != nil {
+			log.Println(err)
+		}
+	}
+}
+
+// writePreviewDialog asks where to write the recolored image
+func writePreviewDialog() {
+	if previewSrc == nil {
+		return
+	}
+	filename, success, err := File("Select file", "*.png", false)
+	if success && (err == nil) {
+		if err := writePreview(filename); err != nil {
+			panic(err)
+		}
+	}
 }
 
 func update(screen *ebiten.Image) (e error) {
 	px, py := ebiten.CursorPosition()
 	wx, wy := ebiten.MouseWheel()
 	cursor := image.Pt(px, py)
+	// the picker image is only made once there is a window, so headless exports never touch ebiten
+	if picker == nil {
+		picker, _ = ebiten.NewImageFromImage(pickerImg, ebiten.FilterDefault)
+	}
 	b := picker.Bounds()
 
-	var ctrlDown bool
-
-	// fullscreen
-	if inpututil.IsKeyJustReleased(ebiten.KeyF) {
-		ebiten.SetFullscreen(!ebiten.IsFullscreen())
-	}
-	// export
-	if inpututil.IsKeyJustReleased(ebiten.KeyE) {
-		// TODO figure out which type of file to export
-		filename, success, err := File("Select file", "*.gpl", false)
-		if success && (err == nil) {
-			filedata := exportGPL()
-			filebytes := []byte(filedata)
-			err := ioutil.WriteFile(filename, filebytes, 0644)
-			if err != nil {
-				panic(err)
-			}
-		}
-	}
-	// ctrl button
-	if inpututil.IsKeyJustPressed(ebiten.KeyControl) {
-		ctrlDown = true
-	}
-	if inpututil.IsKeyJustReleased(ebiten.KeyControl) {
-		ctrlDown = false
+	// the wheel scrolls the library grid or history strip while they are shown
+	if libraryVisible && (wx != 0 || wy != 0) {
+		scrollLibrary(wx + wy)
+		wx, wy = 0, 0
 	}
-	// copy
-	if inpututil.IsKeyJustReleased(ebiten.KeyC) {
-		copy = true
+	if historyVisible && (wx != 0 || wy != 0) {
+		scrollHistory(wx + wy)
+		wx, wy = 0, 0
 	}
-	// change step mod
+
+	// typed text takes priority over the key bindings
+	entry.update()
+
+	// ctrl scales the step of keyboard changes
+	ctrlDown = ebiten.IsKeyPressed(ebiten.KeyControl)
 	if ctrlDown {
 		stepmod = 10
 	} else {
 		stepmod = 1
 	}
-	// change stops
-	if inpututil.IsKeyJustReleased(ebiten.KeyEqual) {
-		stops++
-	}
-	if inpututil.IsKeyJustReleased(ebiten.KeyMinus) {
-		stops--
-	}
+	runActions()
+
 	// keep stops within bounds
 	if stops > stopmax {
 		stops = stopmax
 	} else if stops < stopmin {
 		stops = stopmin
 	}
-	// change step
-	if inpututil.IsKeyJustReleased(ebiten.KeyRightBracket) {
-		step += stepmod
-	}
-	if inpututil.IsKeyJustReleased(ebiten.KeyLeftBracket) {
-		step -= stepmod
+	if selected >= stops {
+		selected = -1
 	}
 	// keep step within bounds
 	if step > stepmax {
@@ -236,13 +536,10 @@ func update(screen *ebiten.Image) (e error) {
 	} else if step < stepmin {
 		step = stepmin
 	}
-	// change distance
-	if inpututil.IsKeyJustReleased(ebiten.KeyUp) {
-		distance += step * stepmod
-	}
-	if inpututil.IsKeyJustReleased(ebiten.KeyDown) {
-		distance -= step * stepmod
+	if wx != 0 || wy != 0 {
+		historyWheel = historySettle
 	}
+	// change distance
 	if wx != 0 {
 		distance -= int(math.Round(wx)) * step
 	}
@@ -252,27 +549,45 @@ func update(screen *ebiten.Image) (e error) {
 	} else if distance < -screenW {
 		distance = -screenW
 	}
+	// guide lines under the cursor can be grabbed
+	if !dragging {
+		hoverStop = -1
+		if cursor.In(b) && !libraryVisible {
+			hoverStop = stopAt(px)
+		}
+	}
 	// change primary position
 	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
-		if cursor.In(b) {
+		if libraryVisible {
+			clickLibrary(cursor)
+		} else if hoverStop >= 0 {
+			dragging = true
+			grabStop = hoverStop
+		} else if cursor.In(b) {
 			dragging = true
 			primary = px
 			brightness = py
+		} else {
+			clickHistory(cursor)
 		}
 	} else if dragging && inpututil.IsMouseButtonJustReleased(ebiten.MouseButtonLeft) {
 		dragging = false
-		distance = px - primary
+		if grabStop >= 0 {
+			dragGuide(px, ebiten.IsKeyPressed(ebiten.KeyShift))
+			grabStop = -1
+		} else {
+			distance = px - primary
+		}
+	} else if dragging && grabStop >= 0 {
+		// shift solves for the distance instead of overriding the stop
+		dragGuide(px, ebiten.IsKeyPressed(ebiten.KeyShift))
 	} else if dragging {
 		brightness = py
 		distance = px - primary
-	} else if inpututil.IsKeyJustReleased(ebiten.KeyLeft) {
-		primary -= step * stepmod
-	} else if inpututil.IsKeyJustReleased(ebiten.KeyRight) {
-		primary += step * stepmod
-	} else if inpututil.IsKeyJustReleased(ebiten.KeyPageUp) {
-		brightness -= step * stepmod
-	} else if inpututil.IsKeyJustReleased(ebiten.KeyPageDown) {
-		brightness += step * stepmod
+	}
+	// right clicking a dragged guide line puts it back in the sequence
+	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonRight) && hoverStop >= 0 {
+		delete(overrides, hoverStop)
 	}
 	if wy != 0 {
 		brightness -= int(math.Round(wy)) * (step * stepmod)
@@ -290,19 +605,8 @@ func update(screen *ebiten.Image) (e error) {
 		primary -= screenW
 	}
 
-	for i := range stoplist {
-		if i == stops {
-			break
-		}
-		switch i {
-		case 0:
-			stoplist[i].setVal(float64(primary))
-		case 1:
-			stoplist[i].setVal(float64(primary + distance))
-		default:
-			stoplist[i].setVal(golden.Next(stoplist[i-2].val, stoplist[i-1].val))
-		}
-	}
+	updateStops()
+	recordHistory()
 
 	// if an error occurred or we don't need to draw, there's nothing left to do
 	if (e != nil) || ebiten.IsDrawingSkipped() {
@@ -317,15 +621,6 @@ func update(screen *ebiten.Image) (e error) {
 	op.SourceRect = &b
 	screen.DrawImage(picker, op)
 
-	// set colors in a loop by itself
-	// this is done to prevent the drawing from pulling colors from the guides
-	for i := range stoplist {
-		if i == stops {
-			break
-		}
-		stoplist[i].setColor(screen.At(int(math.Round(stoplist[i].off)), brightness))
-	}
-
 	bright := uint8(brightness / 2)
 
 	// each selected color box image will share a generic bounds rectangle so they are the same size
@@ -339,7 +634,15 @@ func update(screen *ebiten.Image) (e error) {
 			break
 		}
 		// draw the guide line in the negtive color from the value of the stop
-		ebitenutil.DrawLine(screen, stoplist[i].off, 0, stoplist[i].off, float64(pickerH), stoplist[i].negative())
+		drawGuide(screen, i)
+		// mark the picker position of stops placed away from the brightness line
+		if stoplist[i].py != brightness {
+			ebitenutil.DrawRect(screen, stoplist[i].off-3, float64(stoplist[i].py-3), 7, 7, stoplist[i].negative())
+		}
+		// the recolor preview takes the place of the color boxes
+		if previewImg != nil {
+			continue
+		}
 		// draw the box that represents this color
 		stopOffset := i * (selectedBounds.Max.X + padding)
 		stopBounds := image.Rect(padding+stopOffset, selectedMinY, padding+stopOffset+selectedBounds.Max.X, selectedMaxY)
@@ -349,11 +652,37 @@ func update(screen *ebiten.Image) (e error) {
 		stopOptions.SourceRect = &selectedBounds
 		stopOptions.GeoM.Translate(float64(stopBounds.Min.X), float64(stopBounds.Min.Y))
 		screen.DrawImage(stopImg, stopOptions)
+		if i == selected {
+			drawSelection(screen, stopBounds)
+		}
+		// label the box with the hex code and the closest named colors
+		ebitenutil.DebugPrintAt(screen, strings.Join(append([]string{stopTitle(i), stoplist[i].hex()}, stoplist[i].names()...), "\n"), stopBounds.Min.X+4, stopBounds.Max.Y-86)
+		// show the original color above the snapped one
+		if snapTarget != nil {
+			ebitenutil.DrawRect(screen, float64(stopBounds.Min.X), float64(stopBounds.Min.Y), float64(stopBounds.Dx()), float64(outputH/4), stoplist[i].orig)
+		}
 	}
 	ebitenutil.DrawLine(screen, 0, float64(brightness), float64(screenW), float64(brightness), color.RGBA{bright, bright, bright, 255})
 
-	// debug info
-	ebitenutil.DebugPrint(screen, fmt.Sprintf("FPS: %v TPS: %v\nx: %d, y: %d, bright: %v, steps: %d, stops: %d", ebiten.CurrentFPS(), ebiten.CurrentTPS(), px, py, bright, step, stops))
+	// draw the gradient strip under the color boxes
+	updateGradient()
+	gradientOptions := &ebiten.DrawImageOptions{}
+	gradientOptions.GeoM.Translate(float64(padding), float64(selectedMaxY+(padding-gradientH)/2))
+	screen.DrawImage(gradientImg, gradientOptions)
+
+	// draw the recolor preview centered in the output area
+	updatePreview()
+	if previewImg != nil {
+		w, h := previewImg.Size()
+		previewOptions := &ebiten.DrawImageOptions{}
+		previewOptions.GeoM.Translate(float64((screenW-w)/2), float64(selectedMinY+(outputH-h)/2))
+		screen.DrawImage(previewImg, previewOptions)
+	}
+
+	drawHistory(screen)
+	drawLibrary(screen)
+	drawHUD(screen)
+	drawHelp(screen)
 
 	return
 }
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+
+	"github.com/jeffchannell/phibar/main/resources/names"
+)
+
+// nameTable matches colors against a list of named colors
+type nameTable struct {
+	label  string
+	colors []names.Color
+	labs   []labColor // colors converted for matching, filled in when first needed
+	cache  map[color.RGBA]nameMatch
+}
+
+// nameMatch is the closest named color and its distance
+type nameMatch struct {
+	name string
+	dE   float64
+}
+
+// nameTables are the named color lists shown for each stop
+var nameTables = []*nameTable{
+	{label: "css", colors: names.CSS},
+	{label: "x11", colors: names.X11},
+	{label: "xkcd", colors: names.XKCD},
+}
+
+// nearest finds the named color closest to c by ΔE2000
+func (t *nameTable) nearest(c color.RGBA) nameMatch {
+	if m, ok := t.cache[c]; ok {
+		return m
+	}
+	if t.labs == nil {
+		t.labs = make([]labColor, len(t.colors))
+		for i, n := range t.colors {
+			t.labs[i] = rgbToLab(color.RGBA{uint8(n.RGB >> 16), uint8(n.RGB >> 8), uint8(n.RGB), 255})
+		}
+		t.cache = map[color.RGBA]nameMatch{}
+	}
+	target := rgbToLab(c)
+	m := nameMatch{dE: math.Inf(1)}
+	for i, l := range t.labs {
+		if d := deltaE2000(target, l); d < m.dE {
+			m = nameMatch{t.colors[i].Name, d}
+		}
+	}
+	t.cache[c] = m
+	return m
+}
+
+// names generates the display strings for the closest named colors
+func (s *colorStop) names() []string {
+	c := color.RGBA{s.r, s.g, s.b, 255}
+	lines := make([]string, len(nameTables))
+	for i, t := range nameTables {
+		m := t.nearest(c)
+		lines[i] = fmt.Sprintf("%s: %s (%.1f)", t.label, m.name, m.dE)
+	}
+	return lines
+}
+
+// stopLabel names the stop at index i in palette entries, the typed name or the generated one
+// with the closest CSS name, and stops changed by hand are marked as edited
+func stopLabel(i int) string {
+	name, typed := stopTitles[i]
+	if !typed {
+		css := nameTables[0].nearest(color.RGBA{stoplist[i].r, stoplist[i].g, stoplist[i].b, 255}).name
+		name = fmt.Sprintf("%s (%s)", stoplist[i].title(), css)
+	}
+	if stopEdited(i) {
+		name += " (edited)"
+	}
+	return name
+}
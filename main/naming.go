@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+)
+
+var (
+	// paletteTitle overrides the generated palette name when set
+	paletteTitle string
+	// stopTitles override the generated stop names, by index
+	stopTitles = map[int]string{}
+
+	// hueWords names each 30 degree slice of the hue circle, starting at red
+	hueWords = []string{"Red", "Orange", "Yellow", "Lime", "Green", "Jade", "Cyan", "Azure", "Blue", "Violet", "Magenta", "Rose"}
+
+	slugRe = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+// hueWord names the hue, in degrees
+func hueWord(h float64) string {
+	return hueWords[int(math.Mod(h+15, 360)/30)%len(hueWords)]
+}
+
+// saturationWord describes how colorful a saturation (0-1) is
+func saturationWord(s float64) string {
+	switch {
+	case s < 0.15:
+		return "Gray"
+	case s < 0.4:
+		return "Muted"
+	case s < 0.7:
+		return "Soft"
+	default:
+		return "Vivid"
+	}
+}
+
+// lightnessWord describes a lightness (0-1), empty for the middle range
+func lightnessWord(l float64) string {
+	switch {
+	case l < 0.2:
+		return "Deep"
+	case l < 0.4:
+		return "Dark"
+	case l < 0.65:
+		return ""
+	case l < 0.85:
+		return "Light"
+	default:
+		return "Pale"
+	}
+}
+
+// moodWord describes the overall lightness (0-1) of a palette as a time of day
+func moodWord(l float64) string {
+	switch {
+	case l < 0.25:
+		return "Midnight"
+	case l < 0.45:
+		return "Dusk"
+	case l < 0.6:
+		return "Noon"
+	case l < 0.75:
+		return "Morning"
+	default:
+		return "Haze"
+	}
+}
+
+// title generates a descriptive name for this stop from its hue, saturation and lightness
+func (s *colorStop) title() string {
+	h, sat, l := rgbToHSL(s.r, s.g, s.b)
+	if sat < 0.15 {
+		return strings.TrimSpace(lightnessWord(l) + " Gray")
+	}
+	return strings.TrimSpace(strings.Join([]string{lightnessWord(l), saturationWord(sat), hueWord(h)}, " "))
+}
+
+// stopTitle is the typed name of the stop at index i, or one generated from its color
+func stopTitle(i int) string {
+	if t, ok := stopTitles[i]; ok {
+		return t
+	}
+	return stoplist[i].title()
+}
+
+// nameSelected asks for a name for the selected stop
+func nameSelected() {
+	if selected < 0 {
+		return
+	}
+	i := selected
+	entry.start(fmt.Sprintf("name stop %d: ", i+1), stopTitles[i], func(s string) {
+		setStopTitle(i, s)
+	})
+}
+
+// setStopTitle overrides the generated name of the stop at index i, an empty name restores it
+func setStopTitle(i int, s string) {
+	if s == "" {
+		delete(stopTitles, i)
+		return
+	}
+	stopTitles[i] = s
+}
+
+// copyStopTitles returns the typed stop names
+func copyStopTitles() map[int]string {
+	titles := make(map[int]string, len(stopTitles))
+	for i, t := range stopTitles {
+		titles[i] = t
+	}
+	return titles
+}
+
+// plainName keeps a name from closing the quotes or comment it is written into
+func plainName(s string) string {
+	return strings.NewReplacer(`"`, "'", "*/", "* /").Replace(s)
+}
+
+// paletteName is the typed palette name, or one generated from the stops
+func paletteName() string {
+	if paletteTitle != "" {
+		return paletteTitle
+	}
+	// average the hue as an angle, weighted by saturation so grays don't pull it around
+	var x, y, sat, light float64
+	for _, s := range activeStops() {
+		h, sa, l := rgbToHSL(s.r, s.g, s.b)
+		x += math.Cos(h*math.Pi/180) * sa
+		y += math.Sin(h*math.Pi/180) * sa
+		sat += sa
+		light += l
+	}
+	n := float64(stops)
+	hue := math.Atan2(y, x) * 180 / math.Pi
+	if hue < 0 {
+		hue += 360
+	}
+	// hues spread evenly around the circle cancel out
+	word := hueWord(hue)
+	if math.Hypot(x, y)/n < 0.1 {
+		word = "Spectrum"
+	}
+	return strings.Join([]string{saturationWord(sat / n), word, moodWord(light / n)}, " ")
+}
+
+// setPaletteTitle overrides the generated palette name, an empty name restores it
+func setPaletteTitle(s string) {
+	paletteTitle = s
+}
+
+// paletteFilename generates the default export filename from the palette name
+func paletteFilename(ext string) string {
+	return strings.Trim(slugRe.ReplaceAllString(strings.ToLower(paletteName()), "-"), "-") + ext
+}
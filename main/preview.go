@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten"
+	"golang.org/x/image/draw"
+)
+
+// dithering modes for the recolor preview
+const (
+	ditherNone = iota
+	ditherFloydSteinberg
+	ditherOrdered
+	ditherModes
+)
+
+var (
+	previewSrc    image.Image   // image being recolored, nil when the preview is off
+	previewThumb  image.Image   // source scaled to fit the output area
+	previewImg    *ebiten.Image // recolored thumbnail drawn in the output area
+	previewDither = ditherNone
+	previewKey    string      // palette and dithering the thumbnail was recolored with
+	previewProxy  image.Image // smaller thumbnail recolored while dragging
+	previewShrink = 3         // how many times smaller the proxy is than the thumbnail
+
+	// bayer is the 4x4 ordered dithering threshold matrix
+	bayer = [4][4]float64{
+		{0, 8, 2, 10},
+		{12, 4, 14, 6},
+		{3, 11, 1, 9},
+		{15, 7, 13, 5},
+	}
+)
+
+// readPreview opens an image to recolor with the palette
+func readPreview(filename string) error {
+	img, err := readImage(filename)
+	if err != nil {
+		return err
+	}
+	setPreview(img)
+	return nil
+}
+
+// setPreview recolors img with the palette in the output area
+func setPreview(img image.Image) {
+	previewSrc = img
+	// scale the source to fit the output area, keeping its aspect ratio
+	b := img.Bounds()
+	scale := math.Min(float64(screenW-padding*2)/float64(b.Dx()), float64(outputH)/float64(b.Dy()))
+	if scale > 1 {
+		scale = 1
+	}
+	thumb := image.NewRGBA(image.Rect(0, 0, int(float64(b.Dx())*scale), int(float64(b.Dy())*scale)))
+	draw.ApproxBiLinear.Scale(thumb, thumb.Bounds(), img, b, draw.Src, nil)
+	previewThumb = thumb
+	proxy := image.NewRGBA(image.Rect(0, 0, thumb.Bounds().Dx()/previewShrink+1, thumb.Bounds().Dy()/previewShrink+1))
+	draw.ApproxBiLinear.Scale(proxy, proxy.Bounds(), thumb, thumb.Bounds(), draw.Src, nil)
+	previewProxy = proxy
+	previewKey = ""
+}
+
+// closePreview turns the recolor preview off
+func closePreview() {
+	previewSrc, previewThumb, previewProxy, previewImg = nil, nil, nil, nil
+	previewLUT = false
+}
+
+// paletteColors returns the colors of the active stops
+func paletteColors() []color.RGBA {
+	colors := make([]color.RGBA, 0, stops)
+	for _, s := range activeStops() {
+		colors = append(colors, color.RGBA{s.r, s.g, s.b, 255})
+	}
+	return colors
+}
+
+// paletteKey identifies the colors of the active stops, for caching work done with them
+func paletteKey() string {
+	var key strings.Builder
+	for _, c := range paletteColors() {
+		key.WriteString(string([]byte{c.R, c.G, c.B}))
+	}
+	return key.String()
+}
+
+// updatePreview recolors the thumbnail when the palette or dithering has changed,
+// a drag changes the palette every frame so only the smaller proxy is recolored until it ends
+func updatePreview() {
+	if previewThumb == nil {
+		return
+	}
+	key := fmt.Sprint(paletteKey(), previewDither, dragging)
+	if previewLUT {
+		key += fmt.Sprint("lut", lutSize, lutMode)
+	}
+	if key == previewKey {
+		return
+	}
+	previewKey = key
+	if !dragging {
+		previewImg, _ = ebiten.NewImageFromImage(applyPreview(previewThumb), ebiten.FilterDefault)
+		return
+	}
+	// scale the recolored proxy back up so it is drawn the same size as the thumbnail
+	thumb := image.NewRGBA(previewThumb.Bounds())
+	draw.NearestNeighbor.Scale(thumb, thumb.Bounds(), applyPreview(previewProxy), previewProxy.Bounds(), draw.Src, nil)
+	previewImg, _ = ebiten.NewImageFromImage(thumb, ebiten.FilterDefault)
+}
+
+// applyPreview recolors src with the palette, or grades it with the LUT when previewing one
+func applyPreview(src image.Image) *image.RGBA {
+	if previewLUT {
+		// the smallest LUT keeps up with a drag, the full size one is built when it ends
+		size := lutSize
+		if dragging {
+			size = lutSizes[0]
+		}
+		return applyLUT(src, cachedLUT(size), size)
+	}
+	return recolor(src, paletteColors(), previewDither)
+}
+
+// exportPreview generates the full size recolored image as a PNG
+func exportPreview() []byte {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, applyPreview(previewSrc)); err != nil {
+		panic(err)
+	}
+	return buf.Bytes()
+}
+
+// writePreview saves the recolored image
+func writePreview(filename string) error {
+	if strings.ToLower(filepath.Ext(filename)) != ".png" {
+		filename += ".png"
+	}
+	return ioutil.WriteFile(filename, exportPreview(), 0644)
+}
+
+// recolor maps every pixel of src to the nearest palette color in L*a*b*
+func recolor(src image.Image, palette []color.RGBA, dither int) *image.RGBA {
+	b := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	labs := make([]labColor, len(palette))
+	for i, c := range palette {
+		labs[i] = rgbToLab(c)
+	}
+	cache := map[color.RGBA]color.RGBA{}
+	nearest := func(c color.RGBA) color.RGBA {
+		if n, ok := cache[c]; ok {
+			return n
+		}
+		n := palette[nearestLab(rgbToLab(c), labs)]
+		cache[c] = n
+		return n
+	}
+	clamp := func(v float64) uint8 {
+		return uint8(math.Max(0, math.Min(255, math.Round(v))))
+	}
+
+	// error carried to the current and next rows for Floyd-Steinberg
+	cur := make([][3]float64, b.Dx()+2)
+	next := make([][3]float64, b.Dx()+2)
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			c := toRGBA(src.At(b.Min.X+x, b.Min.Y+y))
+			v := [3]float64{float64(c.R), float64(c.G), float64(c.B)}
+			switch dither {
+			case ditherFloydSteinberg:
+				for ch := range v {
+					v[ch] += cur[x+1][ch]
+				}
+			case ditherOrdered:
+				t := (bayer[y%4][x%4]/16 - 0.5) * 64
+				for ch := range v {
+					v[ch] += t
+				}
+			}
+			n := nearest(color.RGBA{clamp(v[0]), clamp(v[1]), clamp(v[2]), 255})
+			dst.SetRGBA(x, y, n)
+			if dither == ditherFloydSteinberg {
+				e := [3]float64{v[0] - float64(n.R), v[1] - float64(n.G), v[2] - float64(n.B)}
+				for ch := range e {
+					cur[x+2][ch] += e[ch] * 7 / 16
+					next[x][ch] += e[ch] * 3 / 16
+					next[x+1][ch] += e[ch] * 5 / 16
+					next[x+2][ch] += e[ch] * 1 / 16
+				}
+			}
+		}
+		cur, next = next, cur
+		for i := range next {
+			next[i] = [3]float64{}
+		}
+	}
+	return dst
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"log"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	randomSeed   int64   = -1    // seed of the last random palette, shown so it can be shared
+	randomStops  bool            // random palettes also pick the number of stops
+	randomMode   bool            // random palettes also pick the gradient space and colormap type
+	randomMinDE  float64         // minimum ΔE2000 between every pair of stops
+	randomLightL         = 0.0   // lowest Lab lightness allowed for a stop
+	randomLightH         = 100.0 // highest Lab lightness allowed for a stop
+	randomTries          = 2000  // candidates drawn before giving up on the constraints
+	randomSeeds  int64   = 1e9   // new seeds are below this, to keep them short enough to share
+)
+
+// newRandom generates a random palette from a fresh seed
+func newRandom() {
+	randomize(rand.New(rand.NewSource(time.Now().UnixNano())).Int63n(randomSeeds))
+}
+
+// randomSeedEntry generates the random palette for a typed seed
+func randomSeedEntry(s string) {
+	seed, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || seed < 0 {
+		log.Println(fmt.Errorf("invalid seed %q", s))
+		return
+	}
+	randomize(seed)
+}
+
+// randomize sets the generator parameters from a seed, drawing candidates
+// until one meets the constraints, or keeping the closest when none do
+func randomize(seed int64) {
+	randomSeed = seed
+	rng := rand.New(rand.NewSource(seed))
+	// a seed only reproduces a palette when nothing else moves the stops
+	importList = nil
+	unlockAll()
+	releaseGuides()
+	setEdits(nil)
+
+	best, bestMiss := current(), math.Inf(1)
+	bestGradient, bestColormap := gradientSpace, colormapType
+	for try := 0; try < randomTries; try++ {
+		primary = rng.Intn(screenW)
+		distance = rng.Intn(screenW*2+1) - screenW
+		brightness = rng.Intn(pickerH)
+		if randomStops {
+			stops = stopmin + rng.Intn(stopmax-stopmin+1)
+		}
+		if randomMode {
+			gradientSpace = rng.Intn(gradientSpaces)
+			colormapType = rng.Intn(colormapTypes)
+		}
+		updateStops()
+		miss := randomMiss()
+		if miss < bestMiss {
+			best, bestMiss = current(), miss
+			bestGradient, bestColormap = gradientSpace, colormapType
+		}
+		if miss == 0 {
+			break
+		}
+	}
+	best.restore()
+	gradientSpace, colormapType = bestGradient, bestColormap
+	if bestMiss > 0 {
+		log.Printf("no palette from seed %d met the constraints in %d tries, using the closest", seed, randomTries)
+	}
+}
+
+// randomMiss measures how far the stops are from meeting the constraints, 0 when they do
+func randomMiss() float64 {
+	labs := make([]labColor, stops)
+	miss := 0.0
+	for i, s := range activeStops() {
+		labs[i] = rgbToLab(color.RGBA{s.r, s.g, s.b, 255})
+		miss += math.Max(0, randomLightL-labs[i].l) + math.Max(0, labs[i].l-randomLightH)
+	}
+	for i := range labs {
+		for j := i + 1; j < len(labs); j++ {
+			miss += math.Max(0, randomMinDE-deltaE2000(labs[i], labs[j]))
+		}
+	}
+	return miss
+}
+
+// randomConstraints describes the constraints as they are typed, like "10 30-70 stops mode"
+func randomConstraints() string {
+	parts := []string{strconv.FormatFloat(randomMinDE, 'f', -1, 64)}
+	parts = append(parts, fmt.Sprintf("%g-%g", randomLightL, randomLightH))
+	if randomStops {
+		parts = append(parts, "stops")
+	}
+	if randomMode {
+		parts = append(parts, "mode")
+	}
+	return strings.Join(parts, " ")
+}
+
+// setRandomConstraints reads a minimum ΔE2000 between stops, a lightness band like 30-70,
+// and the words stops and mode to also pick the number of stops and the gradient and colormap
+func setRandomConstraints(s string) error {
+	minDE, lightL, lightH, withStops, withMode := 0.0, 0.0, 100.0, false, false
+	for _, f := range strings.Fields(s) {
+		switch {
+		case f == "stops":
+			withStops = true
+		case f == "mode":
+			withMode = true
+		case strings.Contains(f, "-"):
+			band := strings.SplitN(f, "-", 2)
+			lo, err := strconv.ParseFloat(band[0], 64)
+			if err != nil {
+				return fmt.Errorf("invalid lightness band %q", f)
+			}
+			hi, err := strconv.ParseFloat(band[1], 64)
+			if err != nil || hi < lo {
+				return fmt.Errorf("invalid lightness band %q", f)
+			}
+			lightL, lightH = lo, hi
+		default:
+			v, err := strconv.ParseFloat(f, 64)
+			if err != nil || v < 0 {
+				return fmt.Errorf("invalid constraint %q", f)
+			}
+			minDE = v
+		}
+	}
+	randomMinDE, randomLightL, randomLightH, randomStops, randomMode = minDE, lightL, lightH, withStops, withMode
+	return nil
+}
+
+// randomConstraintsEntry sets the constraints from typed text
+func randomConstraintsEntry(s string) {
+	if err := setRandomConstraints(s); err != nil {
+		log.Println(err)
+	}
+}
+
+// randomValue sets the random palette constraints from the command line
+type randomValue struct{}
+
+func (randomValue) String() string {
+	return randomConstraints()
+}
+
+func (randomValue) Set(s string) error {
+	return setRandomConstraints(s)
+}
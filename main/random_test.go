@@ -0,0 +1,81 @@
+package main
+
+import (
+	"image/color"
+	"reflect"
+	"testing"
+)
+
+func TestSetRandomConstraints(t *testing.T) {
+	defer setRandomConstraints(randomConstraints())
+	tests := []struct {
+		in         string
+		minDE      float64
+		low, high  float64
+		stops, err bool
+	}{
+		{"", 0, 0, 100, false, false},
+		{"10", 10, 0, 100, false, false},
+		{"12.5 30-70 stops", 12.5, 30, 70, true, false},
+		{"mode 5", 5, 0, 100, false, false},
+		{"70-30", 0, 0, 0, false, true},
+		{"-3", 0, 0, 0, false, true},
+		{"lots", 0, 0, 0, false, true},
+	}
+	for _, test := range tests {
+		err := setRandomConstraints(test.in)
+		if (err != nil) != test.err {
+			t.Errorf("%q: error %v", test.in, err)
+			continue
+		}
+		if test.err {
+			continue
+		}
+		if randomMinDE != test.minDE || randomLightL != test.low || randomLightH != test.high || randomStops != test.stops {
+			t.Errorf("%q: got %g %g-%g stops %v", test.in, randomMinDE, randomLightL, randomLightH, randomStops)
+		}
+		if again := randomConstraints(); setRandomConstraints(again) != nil {
+			t.Errorf("%q: %q doesn't parse back", test.in, again)
+		}
+	}
+}
+
+func TestRandomizeReproducible(t *testing.T) {
+	if err := loadSurface(""); err != nil {
+		t.Fatal(err)
+	}
+	defer setRandomConstraints(randomConstraints())
+	if err := setRandomConstraints("10 20-90"); err != nil {
+		t.Fatal(err)
+	}
+	stops = 5
+	for _, seed := range []int64{0, 42, 123456789} {
+		randomize(seed)
+		updateStops()
+		first := paletteColors()
+		// move everything so the second run can't lean on the first
+		primary, distance, brightness = 0, 0, 0
+		randomize(seed)
+		updateStops()
+		if again := paletteColors(); !reflect.DeepEqual(first, again) {
+			t.Errorf("seed %d: %v then %v", seed, first, again)
+		}
+		if randomMiss() == 0 {
+			checkRandomConstraints(t, seed, first)
+		}
+	}
+}
+
+// checkRandomConstraints reports stops breaking the constraints
+func checkRandomConstraints(t *testing.T, seed int64, colors []color.RGBA) {
+	for i, c := range colors {
+		if l := rgbToLab(c).l; l < randomLightL || l > randomLightH {
+			t.Errorf("seed %d: stop %d lightness %.1f outside %g-%g", seed, i, l, randomLightL, randomLightH)
+		}
+		for j := i + 1; j < len(colors); j++ {
+			if d := deltaE2000(rgbToLab(c), rgbToLab(colors[j])); d < randomMinDE {
+				t.Errorf("seed %d: stops %d and %d only %.1f apart", seed, i, j, d)
+			}
+		}
+	}
+}
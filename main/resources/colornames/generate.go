@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"go/format"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// the sources are vendored so the tables don't depend on the host or the network
+const (
+	x11Source  = "./colornames/x11-rgb.txt"  // from the X.Org rgb.txt
+	xkcdSource = "./colornames/xkcd-rgb.txt" // from xkcdURL
+	xkcdURL    = "https://xkcd.com/color/rgb.txt"
+)
+
+func main() {
+	fetch := flag.Bool("fetch", false, "download the xkcd names into the vendored file first")
+	flag.Parse()
+	if *fetch {
+		if err := download(xkcdURL, xkcdSource); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if err := generateFile("X11", "the X11 rgb.txt color names", x11Source, parseX11, "./names/x11.go"); err != nil {
+		log.Fatal(err)
+	}
+	if err := generateFile("XKCD", "the xkcd color survey names", xkcdSource, parseXKCD, "./names/xkcd.go"); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// download replaces the vendored file with the one at url
+func download(url, filename string) error {
+	resp, err := http.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, data, 0644)
+}
+
+// generateFile generates a table from a vendored source file
+func generateFile(name, desc, source string, parse func(string) (string, uint32, bool), output string) error {
+	f, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return generate(name, desc, f, parse, output)
+}
+
+// parseX11 reads "r g b name" lines, skipping names with spaces as they duplicate the CamelCase ones
+func parseX11(line string) (string, uint32, bool) {
+	fields := strings.Fields(line)
+	if len(fields) != 4 || strings.HasPrefix(line, "!") {
+		return "", 0, false
+	}
+	var rgb uint32
+	for _, field := range fields[:3] {
+		v, err := strconv.Atoi(field)
+		if err != nil {
+			return "", 0, false
+		}
+		rgb = rgb<<8 | uint32(v)
+	}
+	return fields[3], rgb, true
+}
+
+// parseXKCD reads "name<tab>#rrggbb" lines
+func parseXKCD(line string) (string, uint32, bool) {
+	fields := strings.Split(line, "\t")
+	if len(fields) < 2 || strings.HasPrefix(line, "#") {
+		return "", 0, false
+	}
+	v, err := strconv.ParseUint(strings.TrimPrefix(strings.TrimSpace(fields[1]), "#"), 16, 32)
+	if err != nil {
+		return "", 0, false
+	}
+	return strings.TrimSpace(fields[0]), uint32(v), true
+}
+
+// generate writes a gofmt-clean Go file declaring the parsed names
+func generate(name, desc string, r io.Reader, parse func(string) (string, uint32, bool), output string) error {
+	src := fmt.Sprintf("// Code generated by colornames/generate.go. DO NOT EDIT.\n\npackage names\n\n// %s lists %s\nvar %s = []Color{\n", name, desc, name)
+	seen := map[string]bool{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		n, rgb, ok := parse(scanner.Text())
+		if !ok || seen[n] {
+			continue
+		}
+		seen[n] = true
+		src += fmt.Sprintf("{%q, 0x%06X},\n", n, rgb)
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	data, err := format.Source([]byte(src + "}\n"))
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(output, data, 0644)
+}
@@ -0,0 +1,665 @@
+// Code generated by colornames/generate.go. DO NOT EDIT.
+
+package names
+
+// X11 lists the X11 rgb.txt color names
+var X11 = []Color{
+	{"snow", 0xFFFAFA},
+	{"GhostWhite", 0xF8F8FF},
+	{"WhiteSmoke", 0xF5F5F5},
+	{"gainsboro", 0xDCDCDC},
+	{"FloralWhite", 0xFFFAF0},
+	{"OldLace", 0xFDF5E6},
+	{"linen", 0xFAF0E6},
+	{"AntiqueWhite", 0xFAEBD7},
+	{"PapayaWhip", 0xFFEFD5},
+	{"BlanchedAlmond", 0xFFEBCD},
+	{"bisque", 0xFFE4C4},
+	{"PeachPuff", 0xFFDAB9},
+	{"NavajoWhite", 0xFFDEAD},
+	{"moccasin", 0xFFE4B5},
+	{"cornsilk", 0xFFF8DC},
+	{"ivory", 0xFFFFF0},
+	{"LemonChiffon", 0xFFFACD},
+	{"seashell", 0xFFF5EE},
+	{"honeydew", 0xF0FFF0},
+	{"MintCream", 0xF5FFFA},
+	{"azure", 0xF0FFFF},
+	{"AliceBlue", 0xF0F8FF},
+	{"lavender", 0xE6E6FA},
+	{"LavenderBlush", 0xFFF0F5},
+	{"MistyRose", 0xFFE4E1},
+	{"white", 0xFFFFFF},
+	{"black", 0x000000},
+	{"DarkSlateGray", 0x2F4F4F},
+	{"DarkSlateGrey", 0x2F4F4F},
+	{"DimGray", 0x696969},
+	{"DimGrey", 0x696969},
+	{"SlateGray", 0x708090},
+	{"SlateGrey", 0x708090},
+	{"LightSlateGray", 0x778899},
+	{"LightSlateGrey", 0x778899},
+	{"gray", 0xBEBEBE},
+	{"grey", 0xBEBEBE},
+	{"LightGrey", 0xD3D3D3},
+	{"LightGray", 0xD3D3D3},
+	{"MidnightBlue", 0x191970},
+	{"navy", 0x000080},
+	{"NavyBlue", 0x000080},
+	{"CornflowerBlue", 0x6495ED},
+	{"DarkSlateBlue", 0x483D8B},
+	{"SlateBlue", 0x6A5ACD},
+	{"MediumSlateBlue", 0x7B68EE},
+	{"LightSlateBlue", 0x8470FF},
+	{"MediumBlue", 0x0000CD},
+	{"RoyalBlue", 0x4169E1},
+	{"blue", 0x0000FF},
+	{"DodgerBlue", 0x1E90FF},
+	{"DeepSkyBlue", 0x00BFFF},
+	{"SkyBlue", 0x87CEEB},
+	{"LightSkyBlue", 0x87CEFA},
+	{"SteelBlue", 0x4682B4},
+	{"LightSteelBlue", 0xB0C4DE},
+	{"LightBlue", 0xADD8E6},
+	{"PowderBlue", 0xB0E0E6},
+	{"PaleTurquoise", 0xAFEEEE},
+	{"DarkTurquoise", 0x00CED1},
+	{"MediumTurquoise", 0x48D1CC},
+	{"turquoise", 0x40E0D0},
+	{"cyan", 0x00FFFF},
+	{"LightCyan", 0xE0FFFF},
+	{"CadetBlue", 0x5F9EA0},
+	{"MediumAquamarine", 0x66CDAA},
+	{"aquamarine", 0x7FFFD4},
+	{"DarkGreen", 0x006400},
+	{"DarkOliveGreen", 0x556B2F},
+	{"DarkSeaGreen", 0x8FBC8F},
+	{"SeaGreen", 0x2E8B57},
+	{"MediumSeaGreen", 0x3CB371},
+	{"LightSeaGreen", 0x20B2AA},
+	{"PaleGreen", 0x98FB98},
+	{"SpringGreen", 0x00FF7F},
+	{"LawnGreen", 0x7CFC00},
+	{"green", 0x00FF00},
+	{"chartreuse", 0x7FFF00},
+	{"MediumSpringGreen", 0x00FA9A},
+	{"GreenYellow", 0xADFF2F},
+	{"LimeGreen", 0x32CD32},
+	{"YellowGreen", 0x9ACD32},
+	{"ForestGreen", 0x228B22},
+	{"OliveDrab", 0x6B8E23},
+	{"DarkKhaki", 0xBDB76B},
+	{"khaki", 0xF0E68C},
+	{"PaleGoldenrod", 0xEEE8AA},
+	{"LightGoldenrodYellow", 0xFAFAD2},
+	{"LightYellow", 0xFFFFE0},
+	{"yellow", 0xFFFF00},
+	{"gold", 0xFFD700},
+	{"LightGoldenrod", 0xEEDD82},
+	{"goldenrod", 0xDAA520},
+	{"DarkGoldenrod", 0xB8860B},
+	{"RosyBrown", 0xBC8F8F},
+	{"IndianRed", 0xCD5C5C},
+	{"SaddleBrown", 0x8B4513},
+	{"sienna", 0xA0522D},
+	{"peru", 0xCD853F},
+	{"burlywood", 0xDEB887},
+	{"beige", 0xF5F5DC},
+	{"wheat", 0xF5DEB3},
+	{"SandyBrown", 0xF4A460},
+	{"tan", 0xD2B48C},
+	{"chocolate", 0xD2691E},
+	{"firebrick", 0xB22222},
+	{"brown", 0xA52A2A},
+	{"DarkSalmon", 0xE9967A},
+	{"salmon", 0xFA8072},
+	{"LightSalmon", 0xFFA07A},
+	{"orange", 0xFFA500},
+	{"DarkOrange", 0xFF8C00},
+	{"coral", 0xFF7F50},
+	{"LightCoral", 0xF08080},
+	{"tomato", 0xFF6347},
+	{"OrangeRed", 0xFF4500},
+	{"red", 0xFF0000},
+	{"HotPink", 0xFF69B4},
+	{"DeepPink", 0xFF1493},
+	{"pink", 0xFFC0CB},
+	{"LightPink", 0xFFB6C1},
+	{"PaleVioletRed", 0xDB7093},
+	{"maroon", 0xB03060},
+	{"MediumVioletRed", 0xC71585},
+	{"VioletRed", 0xD02090},
+	{"magenta", 0xFF00FF},
+	{"violet", 0xEE82EE},
+	{"plum", 0xDDA0DD},
+	{"orchid", 0xDA70D6},
+	{"MediumOrchid", 0xBA55D3},
+	{"DarkOrchid", 0x9932CC},
+	{"DarkViolet", 0x9400D3},
+	{"BlueViolet", 0x8A2BE2},
+	{"purple", 0xA020F0},
+	{"MediumPurple", 0x9370DB},
+	{"thistle", 0xD8BFD8},
+	{"snow1", 0xFFFAFA},
+	{"snow2", 0xEEE9E9},
+	{"snow3", 0xCDC9C9},
+	{"snow4", 0x8B8989},
+	{"seashell1", 0xFFF5EE},
+	{"seashell2", 0xEEE5DE},
+	{"seashell3", 0xCDC5BF},
+	{"seashell4", 0x8B8682},
+	{"AntiqueWhite1", 0xFFEFDB},
+	{"AntiqueWhite2", 0xEEDFCC},
+	{"AntiqueWhite3", 0xCDC0B0},
+	{"AntiqueWhite4", 0x8B8378},
+	{"bisque1", 0xFFE4C4},
+	{"bisque2", 0xEED5B7},
+	{"bisque3", 0xCDB79E},
+	{"bisque4", 0x8B7D6B},
+	{"PeachPuff1", 0xFFDAB9},
+	{"PeachPuff2", 0xEECBAD},
+	{"PeachPuff3", 0xCDAF95},
+	{"PeachPuff4", 0x8B7765},
+	{"NavajoWhite1", 0xFFDEAD},
+	{"NavajoWhite2", 0xEECFA1},
+	{"NavajoWhite3", 0xCDB38B},
+	{"NavajoWhite4", 0x8B795E},
+	{"LemonChiffon1", 0xFFFACD},
+	{"LemonChiffon2", 0xEEE9BF},
+	{"LemonChiffon3", 0xCDC9A5},
+	{"LemonChiffon4", 0x8B8970},
+	{"cornsilk1", 0xFFF8DC},
+	{"cornsilk2", 0xEEE8CD},
+	{"cornsilk3", 0xCDC8B1},
+	{"cornsilk4", 0x8B8878},
+	{"ivory1", 0xFFFFF0},
+	{"ivory2", 0xEEEEE0},
+	{"ivory3", 0xCDCDC1},
+	{"ivory4", 0x8B8B83},
+	{"honeydew1", 0xF0FFF0},
+	{"honeydew2", 0xE0EEE0},
+	{"honeydew3", 0xC1CDC1},
+	{"honeydew4", 0x838B83},
+	{"LavenderBlush1", 0xFFF0F5},
+	{"LavenderBlush2", 0xEEE0E5},
+	{"LavenderBlush3", 0xCDC1C5},
+	{"LavenderBlush4", 0x8B8386},
+	{"MistyRose1", 0xFFE4E1},
+	{"MistyRose2", 0xEED5D2},
+	{"MistyRose3", 0xCDB7B5},
+	{"MistyRose4", 0x8B7D7B},
+	{"azure1", 0xF0FFFF},
+	{"azure2", 0xE0EEEE},
+	{"azure3", 0xC1CDCD},
+	{"azure4", 0x838B8B},
+	{"SlateBlue1", 0x836FFF},
+	{"SlateBlue2", 0x7A67EE},
+	{"SlateBlue3", 0x6959CD},
+	{"SlateBlue4", 0x473C8B},
+	{"RoyalBlue1", 0x4876FF},
+	{"RoyalBlue2", 0x436EEE},
+	{"RoyalBlue3", 0x3A5FCD},
+	{"RoyalBlue4", 0x27408B},
+	{"blue1", 0x0000FF},
+	{"blue2", 0x0000EE},
+	{"blue3", 0x0000CD},
+	{"blue4", 0x00008B},
+	{"DodgerBlue1", 0x1E90FF},
+	{"DodgerBlue2", 0x1C86EE},
+	{"DodgerBlue3", 0x1874CD},
+	{"DodgerBlue4", 0x104E8B},
+	{"SteelBlue1", 0x63B8FF},
+	{"SteelBlue2", 0x5CACEE},
+	{"SteelBlue3", 0x4F94CD},
+	{"SteelBlue4", 0x36648B},
+	{"DeepSkyBlue1", 0x00BFFF},
+	{"DeepSkyBlue2", 0x00B2EE},
+	{"DeepSkyBlue3", 0x009ACD},
+	{"DeepSkyBlue4", 0x00688B},
+	{"SkyBlue1", 0x87CEFF},
+	{"SkyBlue2", 0x7EC0EE},
+	{"SkyBlue3", 0x6CA6CD},
+	{"SkyBlue4", 0x4A708B},
+	{"LightSkyBlue1", 0xB0E2FF},
+	{"LightSkyBlue2", 0xA4D3EE},
+	{"LightSkyBlue3", 0x8DB6CD},
+	{"LightSkyBlue4", 0x607B8B},
+	{"SlateGray1", 0xC6E2FF},
+	{"SlateGray2", 0xB9D3EE},
+	{"SlateGray3", 0x9FB6CD},
+	{"SlateGray4", 0x6C7B8B},
+	{"LightSteelBlue1", 0xCAE1FF},
+	{"LightSteelBlue2", 0xBCD2EE},
+	{"LightSteelBlue3", 0xA2B5CD},
+	{"LightSteelBlue4", 0x6E7B8B},
+	{"LightBlue1", 0xBFEFFF},
+	{"LightBlue2", 0xB2DFEE},
+	{"LightBlue3", 0x9AC0CD},
+	{"LightBlue4", 0x68838B},
+	{"LightCyan1", 0xE0FFFF},
+	{"LightCyan2", 0xD1EEEE},
+	{"LightCyan3", 0xB4CDCD},
+	{"LightCyan4", 0x7A8B8B},
+	{"PaleTurquoise1", 0xBBFFFF},
+	{"PaleTurquoise2", 0xAEEEEE},
+	{"PaleTurquoise3", 0x96CDCD},
+	{"PaleTurquoise4", 0x668B8B},
+	{"CadetBlue1", 0x98F5FF},
+	{"CadetBlue2", 0x8EE5EE},
+	{"CadetBlue3", 0x7AC5CD},
+	{"CadetBlue4", 0x53868B},
+	{"turquoise1", 0x00F5FF},
+	{"turquoise2", 0x00E5EE},
+	{"turquoise3", 0x00C5CD},
+	{"turquoise4", 0x00868B},
+	{"cyan1", 0x00FFFF},
+	{"cyan2", 0x00EEEE},
+	{"cyan3", 0x00CDCD},
+	{"cyan4", 0x008B8B},
+	{"DarkSlateGray1", 0x97FFFF},
+	{"DarkSlateGray2", 0x8DEEEE},
+	{"DarkSlateGray3", 0x79CDCD},
+	{"DarkSlateGray4", 0x528B8B},
+	{"aquamarine1", 0x7FFFD4},
+	{"aquamarine2", 0x76EEC6},
+	{"aquamarine3", 0x66CDAA},
+	{"aquamarine4", 0x458B74},
+	{"DarkSeaGreen1", 0xC1FFC1},
+	{"DarkSeaGreen2", 0xB4EEB4},
+	{"DarkSeaGreen3", 0x9BCD9B},
+	{"DarkSeaGreen4", 0x698B69},
+	{"SeaGreen1", 0x54FF9F},
+	{"SeaGreen2", 0x4EEE94},
+	{"SeaGreen3", 0x43CD80},
+	{"SeaGreen4", 0x2E8B57},
+	{"PaleGreen1", 0x9AFF9A},
+	{"PaleGreen2", 0x90EE90},
+	{"PaleGreen3", 0x7CCD7C},
+	{"PaleGreen4", 0x548B54},
+	{"SpringGreen1", 0x00FF7F},
+	{"SpringGreen2", 0x00EE76},
+	{"SpringGreen3", 0x00CD66},
+	{"SpringGreen4", 0x008B45},
+	{"green1", 0x00FF00},
+	{"green2", 0x00EE00},
+	{"green3", 0x00CD00},
+	{"green4", 0x008B00},
+	{"chartreuse1", 0x7FFF00},
+	{"chartreuse2", 0x76EE00},
+	{"chartreuse3", 0x66CD00},
+	{"chartreuse4", 0x458B00},
+	{"OliveDrab1", 0xC0FF3E},
+	{"OliveDrab2", 0xB3EE3A},
+	{"OliveDrab3", 0x9ACD32},
+	{"OliveDrab4", 0x698B22},
+	{"DarkOliveGreen1", 0xCAFF70},
+	{"DarkOliveGreen2", 0xBCEE68},
+	{"DarkOliveGreen3", 0xA2CD5A},
+	{"DarkOliveGreen4", 0x6E8B3D},
+	{"khaki1", 0xFFF68F},
+	{"khaki2", 0xEEE685},
+	{"khaki3", 0xCDC673},
+	{"khaki4", 0x8B864E},
+	{"LightGoldenrod1", 0xFFEC8B},
+	{"LightGoldenrod2", 0xEEDC82},
+	{"LightGoldenrod3", 0xCDBE70},
+	{"LightGoldenrod4", 0x8B814C},
+	{"LightYellow1", 0xFFFFE0},
+	{"LightYellow2", 0xEEEED1},
+	{"LightYellow3", 0xCDCDB4},
+	{"LightYellow4", 0x8B8B7A},
+	{"yellow1", 0xFFFF00},
+	{"yellow2", 0xEEEE00},
+	{"yellow3", 0xCDCD00},
+	{"yellow4", 0x8B8B00},
+	{"gold1", 0xFFD700},
+	{"gold2", 0xEEC900},
+	{"gold3", 0xCDAD00},
+	{"gold4", 0x8B7500},
+	{"goldenrod1", 0xFFC125},
+	{"goldenrod2", 0xEEB422},
+	{"goldenrod3", 0xCD9B1D},
+	{"goldenrod4", 0x8B6914},
+	{"DarkGoldenrod1", 0xFFB90F},
+	{"DarkGoldenrod2", 0xEEAD0E},
+	{"DarkGoldenrod3", 0xCD950C},
+	{"DarkGoldenrod4", 0x8B6508},
+	{"RosyBrown1", 0xFFC1C1},
+	{"RosyBrown2", 0xEEB4B4},
+	{"RosyBrown3", 0xCD9B9B},
+	{"RosyBrown4", 0x8B6969},
+	{"IndianRed1", 0xFF6A6A},
+	{"IndianRed2", 0xEE6363},
+	{"IndianRed3", 0xCD5555},
+	{"IndianRed4", 0x8B3A3A},
+	{"sienna1", 0xFF8247},
+	{"sienna2", 0xEE7942},
+	{"sienna3", 0xCD6839},
+	{"sienna4", 0x8B4726},
+	{"burlywood1", 0xFFD39B},
+	{"burlywood2", 0xEEC591},
+	{"burlywood3", 0xCDAA7D},
+	{"burlywood4", 0x8B7355},
+	{"wheat1", 0xFFE7BA},
+	{"wheat2", 0xEED8AE},
+	{"wheat3", 0xCDBA96},
+	{"wheat4", 0x8B7E66},
+	{"tan1", 0xFFA54F},
+	{"tan2", 0xEE9A49},
+	{"tan3", 0xCD853F},
+	{"tan4", 0x8B5A2B},
+	{"chocolate1", 0xFF7F24},
+	{"chocolate2", 0xEE7621},
+	{"chocolate3", 0xCD661D},
+	{"chocolate4", 0x8B4513},
+	{"firebrick1", 0xFF3030},
+	{"firebrick2", 0xEE2C2C},
+	{"firebrick3", 0xCD2626},
+	{"firebrick4", 0x8B1A1A},
+	{"brown1", 0xFF4040},
+	{"brown2", 0xEE3B3B},
+	{"brown3", 0xCD3333},
+	{"brown4", 0x8B2323},
+	{"salmon1", 0xFF8C69},
+	{"salmon2", 0xEE8262},
+	{"salmon3", 0xCD7054},
+	{"salmon4", 0x8B4C39},
+	{"LightSalmon1", 0xFFA07A},
+	{"LightSalmon2", 0xEE9572},
+	{"LightSalmon3", 0xCD8162},
+	{"LightSalmon4", 0x8B5742},
+	{"orange1", 0xFFA500},
+	{"orange2", 0xEE9A00},
+	{"orange3", 0xCD8500},
+	{"orange4", 0x8B5A00},
+	{"DarkOrange1", 0xFF7F00},
+	{"DarkOrange2", 0xEE7600},
+	{"DarkOrange3", 0xCD6600},
+	{"DarkOrange4", 0x8B4500},
+	{"coral1", 0xFF7256},
+	{"coral2", 0xEE6A50},
+	{"coral3", 0xCD5B45},
+	{"coral4", 0x8B3E2F},
+	{"tomato1", 0xFF6347},
+	{"tomato2", 0xEE5C42},
+	{"tomato3", 0xCD4F39},
+	{"tomato4", 0x8B3626},
+	{"OrangeRed1", 0xFF4500},
+	{"OrangeRed2", 0xEE4000},
+	{"OrangeRed3", 0xCD3700},
+	{"OrangeRed4", 0x8B2500},
+	{"red1", 0xFF0000},
+	{"red2", 0xEE0000},
+	{"red3", 0xCD0000},
+	{"red4", 0x8B0000},
+	{"DebianRed", 0xD70751},
+	{"DeepPink1", 0xFF1493},
+	{"DeepPink2", 0xEE1289},
+	{"DeepPink3", 0xCD1076},
+	{"DeepPink4", 0x8B0A50},
+	{"HotPink1", 0xFF6EB4},
+	{"HotPink2", 0xEE6AA7},
+	{"HotPink3", 0xCD6090},
+	{"HotPink4", 0x8B3A62},
+	{"pink1", 0xFFB5C5},
+	{"pink2", 0xEEA9B8},
+	{"pink3", 0xCD919E},
+	{"pink4", 0x8B636C},
+	{"LightPink1", 0xFFAEB9},
+	{"LightPink2", 0xEEA2AD},
+	{"LightPink3", 0xCD8C95},
+	{"LightPink4", 0x8B5F65},
+	{"PaleVioletRed1", 0xFF82AB},
+	{"PaleVioletRed2", 0xEE799F},
+	{"PaleVioletRed3", 0xCD6889},
+	{"PaleVioletRed4", 0x8B475D},
+	{"maroon1", 0xFF34B3},
+	{"maroon2", 0xEE30A7},
+	{"maroon3", 0xCD2990},
+	{"maroon4", 0x8B1C62},
+	{"VioletRed1", 0xFF3E96},
+	{"VioletRed2", 0xEE3A8C},
+	{"VioletRed3", 0xCD3278},
+	{"VioletRed4", 0x8B2252},
+	{"magenta1", 0xFF00FF},
+	{"magenta2", 0xEE00EE},
+	{"magenta3", 0xCD00CD},
+	{"magenta4", 0x8B008B},
+	{"orchid1", 0xFF83FA},
+	{"orchid2", 0xEE7AE9},
+	{"orchid3", 0xCD69C9},
+	{"orchid4", 0x8B4789},
+	{"plum1", 0xFFBBFF},
+	{"plum2", 0xEEAEEE},
+	{"plum3", 0xCD96CD},
+	{"plum4", 0x8B668B},
+	{"MediumOrchid1", 0xE066FF},
+	{"MediumOrchid2", 0xD15FEE},
+	{"MediumOrchid3", 0xB452CD},
+	{"MediumOrchid4", 0x7A378B},
+	{"DarkOrchid1", 0xBF3EFF},
+	{"DarkOrchid2", 0xB23AEE},
+	{"DarkOrchid3", 0x9A32CD},
+	{"DarkOrchid4", 0x68228B},
+	{"purple1", 0x9B30FF},
+	{"purple2", 0x912CEE},
+	{"purple3", 0x7D26CD},
+	{"purple4", 0x551A8B},
+	{"MediumPurple1", 0xAB82FF},
+	{"MediumPurple2", 0x9F79EE},
+	{"MediumPurple3", 0x8968CD},
+	{"MediumPurple4", 0x5D478B},
+	{"thistle1", 0xFFE1FF},
+	{"thistle2", 0xEED2EE},
+	{"thistle3", 0xCDB5CD},
+	{"thistle4", 0x8B7B8B},
+	{"gray0", 0x000000},
+	{"grey0", 0x000000},
+	{"gray1", 0x030303},
+	{"grey1", 0x030303},
+	{"gray2", 0x050505},
+	{"grey2", 0x050505},
+	{"gray3", 0x080808},
+	{"grey3", 0x080808},
+	{"gray4", 0x0A0A0A},
+	{"grey4", 0x0A0A0A},
+	{"gray5", 0x0D0D0D},
+	{"grey5", 0x0D0D0D},
+	{"gray6", 0x0F0F0F},
+	{"grey6", 0x0F0F0F},
+	{"gray7", 0x121212},
+	{"grey7", 0x121212},
+	{"gray8", 0x141414},
+	{"grey8", 0x141414},
+	{"gray9", 0x171717},
+	{"grey9", 0x171717},
+	{"gray10", 0x1A1A1A},
+	{"grey10", 0x1A1A1A},
+	{"gray11", 0x1C1C1C},
+	{"grey11", 0x1C1C1C},
+	{"gray12", 0x1F1F1F},
+	{"grey12", 0x1F1F1F},
+	{"gray13", 0x212121},
+	{"grey13", 0x212121},
+	{"gray14", 0x242424},
+	{"grey14", 0x242424},
+	{"gray15", 0x262626},
+	{"grey15", 0x262626},
+	{"gray16", 0x292929},
+	{"grey16", 0x292929},
+	{"gray17", 0x2B2B2B},
+	{"grey17", 0x2B2B2B},
+	{"gray18", 0x2E2E2E},
+	{"grey18", 0x2E2E2E},
+	{"gray19", 0x303030},
+	{"grey19", 0x303030},
+	{"gray20", 0x333333},
+	{"grey20", 0x333333},
+	{"gray21", 0x363636},
+	{"grey21", 0x363636},
+	{"gray22", 0x383838},
+	{"grey22", 0x383838},
+	{"gray23", 0x3B3B3B},
+	{"grey23", 0x3B3B3B},
+	{"gray24", 0x3D3D3D},
+	{"grey24", 0x3D3D3D},
+	{"gray25", 0x404040},
+	{"grey25", 0x404040},
+	{"gray26", 0x424242},
+	{"grey26", 0x424242},
+	{"gray27", 0x454545},
+	{"grey27", 0x454545},
+	{"gray28", 0x474747},
+	{"grey28", 0x474747},
+	{"gray29", 0x4A4A4A},
+	{"grey29", 0x4A4A4A},
+	{"gray30", 0x4D4D4D},
+	{"grey30", 0x4D4D4D},
+	{"gray31", 0x4F4F4F},
+	{"grey31", 0x4F4F4F},
+	{"gray32", 0x525252},
+	{"grey32", 0x525252},
+	{"gray33", 0x545454},
+	{"grey33", 0x545454},
+	{"gray34", 0x575757},
+	{"grey34", 0x575757},
+	{"gray35", 0x595959},
+	{"grey35", 0x595959},
+	{"gray36", 0x5C5C5C},
+	{"grey36", 0x5C5C5C},
+	{"gray37", 0x5E5E5E},
+	{"grey37", 0x5E5E5E},
+	{"gray38", 0x616161},
+	{"grey38", 0x616161},
+	{"gray39", 0x636363},
+	{"grey39", 0x636363},
+	{"gray40", 0x666666},
+	{"grey40", 0x666666},
+	{"gray41", 0x696969},
+	{"grey41", 0x696969},
+	{"gray42", 0x6B6B6B},
+	{"grey42", 0x6B6B6B},
+	{"gray43", 0x6E6E6E},
+	{"grey43", 0x6E6E6E},
+	{"gray44", 0x707070},
+	{"grey44", 0x707070},
+	{"gray45", 0x737373},
+	{"grey45", 0x737373},
+	{"gray46", 0x757575},
+	{"grey46", 0x757575},
+	{"gray47", 0x787878},
+	{"grey47", 0x787878},
+	{"gray48", 0x7A7A7A},
+	{"grey48", 0x7A7A7A},
+	{"gray49", 0x7D7D7D},
+	{"grey49", 0x7D7D7D},
+	{"gray50", 0x7F7F7F},
+	{"grey50", 0x7F7F7F},
+	{"gray51", 0x828282},
+	{"grey51", 0x828282},
+	{"gray52", 0x858585},
+	{"grey52", 0x858585},
+	{"gray53", 0x878787},
+	{"grey53", 0x878787},
+	{"gray54", 0x8A8A8A},
+	{"grey54", 0x8A8A8A},
+	{"gray55", 0x8C8C8C},
+	{"grey55", 0x8C8C8C},
+	{"gray56", 0x8F8F8F},
+	{"grey56", 0x8F8F8F},
+	{"gray57", 0x919191},
+	{"grey57", 0x919191},
+	{"gray58", 0x949494},
+	{"grey58", 0x949494},
+	{"gray59", 0x969696},
+	{"grey59", 0x969696},
+	{"gray60", 0x999999},
+	{"grey60", 0x999999},
+	{"gray61", 0x9C9C9C},
+	{"grey61", 0x9C9C9C},
+	{"gray62", 0x9E9E9E},
+	{"grey62", 0x9E9E9E},
+	{"gray63", 0xA1A1A1},
+	{"grey63", 0xA1A1A1},
+	{"gray64", 0xA3A3A3},
+	{"grey64", 0xA3A3A3},
+	{"gray65", 0xA6A6A6},
+	{"grey65", 0xA6A6A6},
+	{"gray66", 0xA8A8A8},
+	{"grey66", 0xA8A8A8},
+	{"gray67", 0xABABAB},
+	{"grey67", 0xABABAB},
+	{"gray68", 0xADADAD},
+	{"grey68", 0xADADAD},
+	{"gray69", 0xB0B0B0},
+	{"grey69", 0xB0B0B0},
+	{"gray70", 0xB3B3B3},
+	{"grey70", 0xB3B3B3},
+	{"gray71", 0xB5B5B5},
+	{"grey71", 0xB5B5B5},
+	{"gray72", 0xB8B8B8},
+	{"grey72", 0xB8B8B8},
+	{"gray73", 0xBABABA},
+	{"grey73", 0xBABABA},
+	{"gray74", 0xBDBDBD},
+	{"grey74", 0xBDBDBD},
+	{"gray75", 0xBFBFBF},
+	{"grey75", 0xBFBFBF},
+	{"gray76", 0xC2C2C2},
+	{"grey76", 0xC2C2C2},
+	{"gray77", 0xC4C4C4},
+	{"grey77", 0xC4C4C4},
+	{"gray78", 0xC7C7C7},
+	{"grey78", 0xC7C7C7},
+	{"gray79", 0xC9C9C9},
+	{"grey79", 0xC9C9C9},
+	{"gray80", 0xCCCCCC},
+	{"grey80", 0xCCCCCC},
+	{"gray81", 0xCFCFCF},
+	{"grey81", 0xCFCFCF},
+	{"gray82", 0xD1D1D1},
+	{"grey82", 0xD1D1D1},
+	{"gray83", 0xD4D4D4},
+	{"grey83", 0xD4D4D4},
+	{"gray84", 0xD6D6D6},
+	{"grey84", 0xD6D6D6},
+	{"gray85", 0xD9D9D9},
+	{"grey85", 0xD9D9D9},
+	{"gray86", 0xDBDBDB},
+	{"grey86", 0xDBDBDB},
+	{"gray87", 0xDEDEDE},
+	{"grey87", 0xDEDEDE},
+	{"gray88", 0xE0E0E0},
+	{"grey88", 0xE0E0E0},
+	{"gray89", 0xE3E3E3},
+	{"grey89", 0xE3E3E3},
+	{"gray90", 0xE5E5E5},
+	{"grey90", 0xE5E5E5},
+	{"gray91", 0xE8E8E8},
+	{"grey91", 0xE8E8E8},
+	{"gray92", 0xEBEBEB},
+	{"grey92", 0xEBEBEB},
+	{"gray93", 0xEDEDED},
+	{"grey93", 0xEDEDED},
+	{"gray94", 0xF0F0F0},
+	{"grey94", 0xF0F0F0},
+	{"gray95", 0xF2F2F2},
+	{"grey95", 0xF2F2F2},
+	{"gray96", 0xF5F5F5},
+	{"grey96", 0xF5F5F5},
+	{"gray97", 0xF7F7F7},
+	{"grey97", 0xF7F7F7},
+	{"gray98", 0xFAFAFA},
+	{"grey98", 0xFAFAFA},
+	{"gray99", 0xFCFCFC},
+	{"grey99", 0xFCFCFC},
+	{"gray100", 0xFFFFFF},
+	{"grey100", 0xFFFFFF},
+	{"DarkGrey", 0xA9A9A9},
+	{"DarkGray", 0xA9A9A9},
+	{"DarkBlue", 0x00008B},
+	{"DarkCyan", 0x008B8B},
+	{"DarkMagenta", 0x8B008B},
+	{"DarkRed", 0x8B0000},
+	{"LightGreen", 0x90EE90},
+}
@@ -0,0 +1,56 @@
+// Code generated by colornames/generate.go. DO NOT EDIT.
+
+package names
+
+// XKCD lists the xkcd color survey names
+var XKCD = []Color{
+	{"purple", 0x7E1E9C},
+	{"green", 0x15B01A},
+	{"blue", 0x0343DF},
+	{"pink", 0xFF81C0},
+	{"brown", 0x653700},
+	{"red", 0xE50000},
+	{"light blue", 0x95D0FC},
+	{"teal", 0x029386},
+	{"orange", 0xF97306},
+	{"light green", 0x96F97B},
+	{"magenta", 0xC20078},
+	{"yellow", 0xFFFF14},
+	{"sky blue", 0x75BBFD},
+	{"grey", 0x929591},
+	{"lime green", 0x89FE05},
+	{"light purple", 0xBF77F6},
+	{"violet", 0x9A0EEA},
+	{"dark green", 0x033500},
+	{"turquoise", 0x06C2AC},
+	{"lavender", 0xC79FEF},
+	{"dark blue", 0x00035B},
+	{"tan", 0xD1B26F},
+	{"cyan", 0x00FFFF},
+	{"aqua", 0x13EAC9},
+	{"forest green", 0x06470C},
+	{"mauve", 0xAE7181},
+	{"dark purple", 0x35063E},
+	{"bright green", 0x01FF07},
+	{"maroon", 0x650021},
+	{"olive", 0x6E750E},
+	{"salmon", 0xFF796C},
+	{"beige", 0xE6DAA6},
+	{"royal blue", 0x0504AA},
+	{"navy blue", 0x001146},
+	{"lilac", 0xCEA2FD},
+	{"black", 0x000000},
+	{"hot pink", 0xFF028D},
+	{"light brown", 0xAD8150},
+	{"pale green", 0xC7FDB5},
+	{"peach", 0xFFB07C},
+	{"olive green", 0x677A04},
+	{"dark pink", 0xCB416B},
+	{"periwinkle", 0x8E82FE},
+	{"sea green", 0x53FCA1},
+	{"lime", 0xAAFF32},
+	{"indigo", 0x380282},
+	{"mustard", 0xCEB301},
+	{"light pink", 0xFFD1DF},
+	{"white", 0xFFFFFF},
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"image/color"
+	"path/filepath"
+	"strings"
+)
+
+// targetPalette is a fixed set of colors stops can be snapped to
+type targetPalette struct {
+	name   string
+	colors []color.RGBA
+	labs   []labColor // colors converted for matching, filled in when first needed
+}
+
+var (
+	// retroPalettes lists the hardware palettes available for snapping
+	retroPalettes = []targetPalette{
+		{name: "NES", colors: hexList(`
+			7C7C7C 0000FC 0000BC 4428BC 940084 A80020 A81000 881400 503000 007800 006800 005800 004058 000000
+			BCBCBC 0078F8 0058F8 6844FC D800CC E40058 F83800 E45C10 AC7C00 00B800 00A800 00A844 008888
+			F8F8F8 3CBCFC 6888FC 9878F8 F878F8 F85898 F87858 FCA044 F8B800 B8F818 58D854 58F898 00E8D8 787878
+			FCFCFC A4E4FC B8B8F8 D8B8F8 F8B8F8 F8A4C0 F0D0B0 FCE0A8 F8D878 D8F878 B8F8B8 B8F8D8 00FCFC F8D8F8`)},
+		{name: "Game Boy", colors: hexList(`0F380F 306230 8BAC0F 9BBC0F`)},
+		{name: "PICO-8", colors: hexList(`
+			000000 1D2B53 7E2553 008751 AB5236 5F574F C2C3C7 FFF1E8
+			FF004D FFA300 FFEC27 00E436 29ADFF 83769C FF77A8 FFCCAA`)},
+		{name: "C64", colors: hexList(`
+			000000 FFFFFF 68372B 70A4B2 6F3D86 588D43 352879 B8C76F
+			6F4F25 433900 9A6759 444444 6C6C6C 9AD284 6C5EB5 959595`)},
+		{name: "CGA", colors: hexList(`
+			000000 0000AA 00AA00 00AAAA AA0000 AA00AA AA5500 AAAAAA
+			555555 5555FF 55FF55 55FFFF FF5555 FF55FF FFFF55 FFFFFF`)},
+		{name: "EGA", colors: levelCube([]uint8{0x00, 0x55, 0xAA, 0xFF})},
+		{name: "Web-safe", colors: levelCube([]uint8{0x00, 0x33, 0x66, 0x99, 0xCC, 0xFF})},
+	}
+
+	// snapTarget is the palette stops are snapped to, nil when snapping is off
+	snapTarget *targetPalette
+	// snapIndex is the position of snapTarget in retroPalettes, -1 when off
+	snapIndex = -1
+	// snapCustom holds a palette loaded from a file, used as the last snapping option
+	snapCustom *targetPalette
+)
+
+// hexList parses whitespace separated hex colors
+func hexList(s string) []color.RGBA {
+	var colors []color.RGBA
+	for _, field := range strings.Fields(s) {
+		c, err := parseHex(field)
+		if err != nil {
+			panic(err)
+		}
+		colors = append(colors, c)
+	}
+	return colors
+}
+
+// levelCube generates every combination of the channel levels
+func levelCube(levels []uint8) []color.RGBA {
+	var colors []color.RGBA
+	for _, r := range levels {
+		for _, g := range levels {
+			for _, b := range levels {
+				colors = append(colors, color.RGBA{r, g, b, 255})
+			}
+		}
+	}
+	return colors
+}
+
+// cycleSnap moves to the next snapping target, wrapping around to off
+func cycleSnap() {
+	snapIndex++
+	switch {
+	case snapIndex < len(retroPalettes):
+		snapTarget = &retroPalettes[snapIndex]
+	case snapIndex == len(retroPalettes) && snapCustom != nil:
+		snapTarget = snapCustom
+	default:
+		snapIndex = -1
+		snapTarget = nil
+	}
+}
+
+// readSnapTarget loads a palette file and snaps to it
+func readSnapTarget(filename string) error {
+	colors, err := readPalette(filename)
+	if err != nil {
+		return err
+	}
+	snapCustom = &targetPalette{name: filepath.Base(filename), colors: colors}
+	snapIndex = len(retroPalettes)
+	snapTarget = snapCustom
+	return nil
+}
+
+// snapColor returns the target palette color closest to c in L*a*b*
+func snapColor(c color.RGBA) color.RGBA {
+	if snapTarget == nil || len(snapTarget.colors) == 0 {
+		return c
+	}
+	if snapTarget.labs == nil {
+		snapTarget.labs = make([]labColor, len(snapTarget.colors))
+		for i, t := range snapTarget.colors {
+			snapTarget.labs[i] = rgbToLab(t)
+		}
+	}
+	return snapTarget.colors[nearestLab(rgbToLab(c), snapTarget.labs)]
+}
+
+// snapName describes the current snapping target
+func snapName() string {
+	if snapTarget == nil {
+		return "off"
+	}
+	return snapTarget.name
+}
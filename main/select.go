@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/ebitenutil"
+)
+
+// stopEdit nudges a stop's color away from the color the sequence gives it
+type stopEdit struct {
+	Hue   float64 `json:"hue,omitempty"`   // degrees
+	Sat   float64 `json:"sat,omitempty"`   // HSL saturation, -1 to 1
+	Light float64 `json:"light,omitempty"` // HSL lightness, -1 to 1
+}
+
+var (
+	selected = -1 // stop chosen for editing from the keyboard
+
+	editHueStep = 2.0  // degrees of hue per nudge
+	editStep    = 0.02 // saturation or lightness per nudge
+	selectW     = 3    // width of the outline around the selected box
+)
+
+// zero reports whether the edit leaves the color alone
+func (e stopEdit) zero() bool {
+	return e == stopEdit{}
+}
+
+// apply nudges c in HSL
+func (e stopEdit) apply(c color.RGBA) color.RGBA {
+	h, s, l := rgbToHSL(c.R, c.G, c.B)
+	h = math.Mod(h+e.Hue+360, 360)
+	s = math.Max(0, math.Min(1, s+e.Sat))
+	l = math.Max(0, math.Min(1, l+e.Light))
+	return hslToRGB(h, s, l)
+}
+
+// String describes the edit for the HUD
+func (e stopEdit) String() string {
+	return fmt.Sprintf("hue %+.0f sat %+.2f light %+.2f", e.Hue, e.Sat, e.Light)
+}
+
+// applyEdit nudges the generated color by the hand edits
+func (s *colorStop) applyEdit() {
+	if !s.edit.zero() {
+		s.setColor(s.edit.apply(color.RGBA{s.r, s.g, s.b, 255}))
+	}
+}
+
+// stopEdited reports whether the stop at index i was changed by hand rather than generated
+func stopEdited(i int) bool {
+	_, dragged := overrides[i]
+	_, locked := anchors[i]
+	return dragged || locked || !stoplist[i].edit.zero()
+}
+
+// selectStop chooses the stop at index i, ignoring stops that aren't shown
+func selectStop(i int) {
+	if i < stops {
+		selected = i
+	}
+}
+
+// cycleSelection moves the selection forward or back through the stops,
+// starting from the first or last stop when none is selected
+func cycleSelection(d int) {
+	switch {
+	case selected < 0 && d > 0:
+		selected = 0
+	case selected < 0:
+		selected = stops - 1
+	default:
+		selected = ((selected+d)%stops + stops) % stops
+	}
+}
+
+// nudgeSelected changes the edit of the selected stop
+func nudgeSelected(hue, sat, light float64) {
+	if selected < 0 {
+		return
+	}
+	e := &stoplist[selected].edit
+	e.Hue = math.Mod(e.Hue+hue*float64(stepmod), 360)
+	e.Sat = math.Max(-1, math.Min(1, e.Sat+sat*float64(stepmod)))
+	e.Light = math.Max(-1, math.Min(1, e.Light+light*float64(stepmod)))
+}
+
+// lockSelected locks the selected stop to the color it shows now, leaving the palette where it is
+func lockSelected() {
+	if selected < 0 {
+		return
+	}
+	// the edit becomes part of the locked color
+	s := &stoplist[selected]
+	anchors[selected] = color.RGBA{s.r, s.g, s.b, 255}
+	s.edit = stopEdit{}
+}
+
+// resetSelected returns the selected stop to its golden position and color
+func resetSelected() {
+	if selected < 0 {
+		return
+	}
+	stoplist[selected].edit = stopEdit{}
+	delete(overrides, selected)
+	delete(anchors, selected)
+}
+
+// copyEdits returns the edits of every stop
+func copyEdits() []stopEdit {
+	edits := make([]stopEdit, len(stoplist))
+	for i := range stoplist {
+		edits[i] = stoplist[i].edit
+	}
+	return edits
+}
+
+// sameEdits reports whether two sets of edits change the stops the same way
+func sameEdits(a, b []stopEdit) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// setEdits restores the edits of every stop
+func setEdits(edits []stopEdit) {
+	for i := range stoplist {
+		stoplist[i].edit = stopEdit{}
+		if i < len(edits) {
+			stoplist[i].edit = edits[i]
+		}
+	}
+}
+
+// drawSelection outlines the box of the selected stop
+func drawSelection(screen *ebiten.Image, box image.Rectangle) {
+	x, y := float64(box.Min.X-selectW), float64(box.Min.Y-selectW)
+	w, h, t := float64(box.Dx()+selectW*2), float64(box.Dy()+selectW*2), float64(selectW)
+	ebitenutil.DrawRect(screen, x, y, w, t, hudText)
+	ebitenutil.DrawRect(screen, x, y+h-t, w, t, hudText)
+	ebitenutil.DrawRect(screen, x, y, t, h, hudText)
+	ebitenutil.DrawRect(screen, x+w-t, y, t, h, hudText)
+}
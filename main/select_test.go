@@ -0,0 +1,24 @@
+package main
+
+import "testing"
+
+func TestCycleSelection(t *testing.T) {
+	defer func(s, n int) { selected, stops = s, n }(selected, stops)
+	stops = 5
+	tests := []struct {
+		from, d, want int
+	}{
+		{-1, 1, 0},
+		{-1, -1, 4},
+		{0, -1, 4},
+		{4, 1, 0},
+		{2, 1, 3},
+	}
+	for _, test := range tests {
+		selected = test.from
+		cycleSelection(test.d)
+		if selected != test.want {
+			t.Errorf("from %d by %d: selected %d, want %d", test.from, test.d, selected, test.want)
+		}
+	}
+}
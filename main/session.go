@@ -0,0 +1,247 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// sessionExt is the extension of saved session files
+const sessionExt = ".phibar"
+
+// session is the generator state saved between runs
+type session struct {
+	Primary    int              `json:"primary"`
+	Distance   int              `json:"distance"`
+	Brightness int              `json:"brightness"`
+	Step       int              `json:"step"`
+	Stops      int              `json:"stops"`
+	Surface    string           `json:"surface,omitempty"`
+	Title      string           `json:"title,omitempty"`
+	StopTitles map[int]string   `json:"stopTitles,omitempty"`
+	Gradient   string           `json:"gradient"`
+	Colormap   string           `json:"colormap"`
+	Midpoint   *int             `json:"midpoint,omitempty"`
+	Snap       string           `json:"snap,omitempty"`
+	LUTSize    int              `json:"lutSize"`
+	LUTMode    int              `json:"lutMode"`
+	Dither     int              `json:"dither"`
+	Anchors    map[int]string   `json:"anchors,omitempty"`
+	Overrides  map[int]float64  `json:"overrides,omitempty"`
+	Ratio      float64          `json:"ratio,omitempty"`
+	Edits      map[int]stopEdit `json:"edits,omitempty"`
+	Import     []string         `json:"import,omitempty"`
+}
+
+// sessionPath is where the last session is kept, under the XDG state directory
+func sessionPath() string {
+	dir := os.Getenv("XDG_STATE_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".local", "state")
+	}
+	return filepath.Join(dir, strings.ToLower(windowTitle), "session"+sessionExt)
+}
+
+// hexString formats a color as #RRGGBB
+func hexString(c color.RGBA) string {
+	return fmt.Sprintf("#%02X%02X%02X", c.R, c.G, c.B)
+}
+
+// currentSession captures the generator state
+func currentSession() session {
+	s := session{
+		Primary:    primary,
+		Distance:   distance,
+		Brightness: brightness,
+		Step:       step,
+		Stops:      stops,
+		Surface:    surfaceFile,
+		Title:      paletteTitle,
+		StopTitles: copyStopTitles(),
+		Gradient:   gradientNames[gradientSpace],
+		Colormap:   colormapNames[colormapType],
+		LUTSize:    lutSize,
+		LUTMode:    lutMode,
+		Dither:     previewDither,
+		Anchors:    map[int]string{},
+		Overrides:  copyOverrides(),
+		Ratio:      ratio,
+		Edits:      map[int]stopEdit{},
+	}
+	if colormapMid >= 0 {
+		mid := colormapMid
+		s.Midpoint = &mid
+	}
+	if snapTarget != nil && snapTarget != snapCustom {
+		s.Snap = snapTarget.name
+	}
+	for i, c := range anchors {
+		s.Anchors[i] = hexString(c)
+	}
+	for i := range stoplist {
+		if !stoplist[i].edit.zero() {
+			s.Edits[i] = stoplist[i].edit
+		}
+	}
+	for _, imp := range importList {
+		s.Import = append(s.Import, hexString(imp.color))
+	}
+	return s
+}
+
+// apply restores the generator state from the session
+func (s session) apply() error {
+	if err := loadSurface(s.Surface); err != nil {
+		// a moved or deleted surface image shouldn't lose the rest of the session
+		log.Println(err)
+		if err := loadSurface(""); err != nil {
+			return err
+		}
+	}
+	// imports move the parameters, so they go first
+	importList = nil
+	if len(s.Import) > 0 {
+		colors, err := importHex([]byte(strings.Join(s.Import, "\n")))
+		if err != nil {
+			return err
+		}
+		if err := setImport(colors); err != nil {
+			return err
+		}
+	}
+	unlockAll()
+	releaseGuides()
+	if s.Ratio > 0 {
+		ratio = s.Ratio
+	}
+	for i, h := range s.Anchors {
+		c, err := parseHex(h)
+		if err != nil {
+			return err
+		}
+		anchors[i] = c
+	}
+	for i, v := range s.Overrides {
+		overrides[i] = v
+	}
+	edits := make([]stopEdit, len(stoplist))
+	for i, e := range s.Edits {
+		if i >= 0 && i < len(edits) {
+			edits[i] = e
+		}
+	}
+	setEdits(edits)
+
+	primary, distance, brightness, step, stops = s.Primary, s.Distance, s.Brightness, s.Step, s.Stops
+	paletteTitle = s.Title
+	stopTitles = map[int]string{}
+	for i, t := range s.StopTitles {
+		stopTitles[i] = t
+	}
+	for i, n := range gradientNames {
+		if n == s.Gradient {
+			gradientSpace = i
+		}
+	}
+	if err := setColormapType(s.Colormap); err != nil {
+		return err
+	}
+	colormapMid = -1
+	if s.Midpoint != nil && *s.Midpoint >= 0 {
+		colormapMid = *s.Midpoint
+	}
+	snapIndex, snapTarget = -1, nil
+	for i := range retroPalettes {
+		if retroPalettes[i].name == s.Snap {
+			snapIndex, snapTarget = i, &retroPalettes[i]
+		}
+	}
+	// the file may have been edited by hand, so only known settings are taken
+	for _, size := range lutSizes {
+		if size == s.LUTSize {
+			lutSize = size
+		}
+	}
+	if s.LUTMode >= 0 && s.LUTMode < lutModes {
+		lutMode = s.LUTMode
+	}
+	if s.Dither >= 0 && s.Dither < ditherModes {
+		previewDither = s.Dither
+	}
+	clampParams()
+	return nil
+}
+
+// exportSession generates a session file holding the generator state
+func exportSession() []byte {
+	data, err := json.MarshalIndent(currentSession(), "", "  ")
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
+
+// readSession restores the generator state from a session file
+func readSession(filename string) error {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	var s session
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("%s: %v", filename, err)
+	}
+	return s.apply()
+}
+
+// writeSession saves the generator state, creating the directory if needed
+func writeSession(filename string) error {
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, exportSession(), 0644)
+}
+
+// openFilter matches every file the open dialog can read
+func openFilter() string {
+	return importFilter() + " *" + sessionExt
+}
+
+// readOpen opens a session or palette file
+func readOpen(filename string) error {
+	if strings.ToLower(filepath.Ext(filename)) == sessionExt {
+		return readSession(filename)
+	}
+	return readImport(filename)
+}
+
+// surfaceValue sets the picker surface from the command line
+type surfaceValue struct{}
+
+func (surfaceValue) String() string {
+	return surfaceFile
+}
+
+func (surfaceValue) Set(filename string) error {
+	return loadSurface(filename)
+}
+
+// colormapValue sets the colormap type from the command line
+type colormapValue struct{}
+
+func (colormapValue) String() string {
+	return colormapNames[colormapType]
+}
+
+func (colormapValue) Set(name string) error {
+	return setColormapType(name)
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestSessionRoundTrip(t *testing.T) {
+	if err := loadSurface(""); err != nil {
+		t.Fatal(err)
+	}
+	primary, distance, brightness, step, stops = 300, 120, 200, 5, 6
+	ratio = 1.25
+	paletteTitle = "Test"
+	stopTitles = map[int]string{2: "Third"}
+	lutSize, lutMode, previewDither = lutSizes[2], 1, 1
+	overrides = map[int]float64{4: 900}
+	stoplist[1].edit = stopEdit{Hue: 10, Light: 0.1}
+	updateStops()
+	want := currentSession()
+
+	var got session
+	if err := json.Unmarshal(exportSession(), &got); err != nil {
+		t.Fatal(err)
+	}
+	primary, distance, ratio, paletteTitle, lutSize = 0, 0, 1, "", lutSizes[0]
+	stopTitles, overrides = map[int]string{}, map[int]float64{}
+	setEdits(nil)
+	if err := got.apply(); err != nil {
+		t.Fatal(err)
+	}
+	updateStops()
+	if s := currentSession(); !reflect.DeepEqual(s, want) {
+		t.Errorf("restored %+v, want %+v", s, want)
+	}
+	setEdits(nil)
+	releaseGuides()
+}
+
+func TestSessionApplyInvalid(t *testing.T) {
+	lutSize, lutMode, previewDither = lutSizes[1], 0, 0
+	tests := []session{
+		{Stops: 4, Colormap: colormapNames[colormapOff], Surface: "/nonexistent/surface.png"},
+		{Stops: 4, Colormap: colormapNames[colormapOff], LUTSize: 1, LUTMode: -1, Dither: -2},
+		{Stops: 4, Colormap: colormapNames[colormapOff], LUTSize: 1 << 20, LUTMode: lutModes, Dither: ditherModes},
+	}
+	for i, s := range tests {
+		if err := s.apply(); err != nil {
+			t.Errorf("%d: %v", i, err)
+			continue
+		}
+		if surfaceFile != "" {
+			t.Errorf("%d: surface is %q, want the generated picker", i, surfaceFile)
+		}
+		if lutSize != lutSizes[1] || lutMode != 0 || previewDither != 0 {
+			t.Errorf("%d: lut %d %d dither %d, want the previous settings", i, lutSize, lutMode, previewDither)
+		}
+	}
+}
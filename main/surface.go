@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"math"
+
+	resources "github.com/jeffchannell/phibar/main/resources/images"
+
+	"golang.org/x/image/draw"
+)
+
+// surfaceFile is the image used as the picker surface, empty for the generated gradient
+var surfaceFile string
+
+// loadSurface decodes the image file, or the generated gradient when filename is empty,
+// and uses it as the picker surface
+func loadSurface(filename string) error {
+	var img image.Image
+	var err error
+	if filename == "" {
+		img, _, err = image.Decode(bytes.NewReader(resources.Palette_png))
+	} else {
+		img, err = readImage(filename)
+	}
+	if err != nil {
+		return err
+	}
+	surfaceFile = filename
+	setSurface(img)
+	return nil
+}
+
+// setSurface scales img to the picker size and uses it as the picker surface,
+// always as 8-bit RGBA since the stops take their colors straight from it
+func setSurface(img image.Image) {
+	r := image.Rect(0, 0, pickerW, pickerH)
+	surface := image.NewRGBA(r)
+	if img.Bounds() == r {
+		draw.Draw(surface, r, img, r.Min, draw.Src)
+	} else {
+		draw.CatmullRom.Scale(surface, r, img, img.Bounds(), draw.Src, nil)
+	}
+	pickerImg = surface
+	// the window makes its image from the new surface on the next frame
+	picker = nil
+	// the cached colors belong to the previous surface
+	pickerLab = nil
+}
+
+// surfaceSearchStep is the spacing of the coarse pass when searching the picker
+const surfaceSearchStep = 4
+
+// surfaceTolerance is the ΔE2000 above which the analytic solution is refined by searching
+const surfaceTolerance = 0.5
+
+// surfaceRefine is how far around the analytic solution the picker image is searched,
+// the decoded gradient can differ from the formula by a step per channel
+const surfaceRefine = 2
+
+// nearestPickerPoint finds the picker coordinates whose color is closest to c,
+// returning the coordinates and the remaining ΔE2000 distance
+func nearestPickerPoint(c color.Color) (x, y int, dE float64) {
+	return nearestPoint(toRGBA(c), pickerImg.Bounds(), func(px, py int) color.RGBA {
+		return toRGBA(pickerImg.At(px, py))
+	})
+}
+
+// nearestPoint searches the bounds for the sampled color closest to c
+func nearestPoint(c color.RGBA, b image.Rectangle, sample func(x, y int) color.RGBA) (x, y int, dE float64) {
+	target := rgbToLab(c)
+	dE = math.Inf(1)
+	check := func(px, py int) {
+		if d := deltaE2000(target, rgbToLab(sample(px, py))); d < dE {
+			x, y, dE = px, py, d
+		}
+	}
+	// coarse pass over the whole surface
+	for py := b.Min.Y; py < b.Max.Y; py += surfaceSearchStep {
+		for px := b.Min.X; px < b.Max.X; px += surfaceSearchStep {
+			check(px, py)
+		}
+	}
+	// fine pass around the best coarse match
+	cx, cy := x, y
+	for py := cy - surfaceSearchStep; py <= cy+surfaceSearchStep; py++ {
+		for px := cx - surfaceSearchStep; px <= cx+surfaceSearchStep; px++ {
+			if image.Pt(px, py).In(b) {
+				check(px, py)
+			}
+		}
+	}
+	return
+}
+
+// surfaceHue calculates the fully saturated color at picker x
+func surfaceHue(x int) color.RGBA {
+	v := uint8(x % 256)
+	switch x / 256 {
+	// red to yellow (FF0000 to FFFF00)
+	case 0:
+		return color.RGBA{255, v, 0, 255}
+	// yellow to green (FFFF00 to 00FF00)
+	case 1:
+		return color.RGBA{255 - v, 255, 0, 255}
+	// green to cyan (00FF00 to 00FFFF)
+	case 2:
+		return color.RGBA{0, 255, v, 255}
+	// cyan to blue (00FFFF to 0000FF)
+	case 3:
+		return color.RGBA{0, 255 - v, 255, 255}
+	// blue to pink (0000FF to FF00FF)
+	case 4:
+		return color.RGBA{v, 0, 255, 255}
+	// violet to red
+	default:
+		return color.RGBA{255, 0, 255 - v, 255}
+	}
+}
+
+// surfaceColor calculates the generated picker color at x, y without sampling the image,
+// the top half fades from white and the bottom half fades to black
+func surfaceColor(x, y int) color.RGBA {
+	c := surfaceHue(x)
+	mix := func(v uint8, a int, to int) uint8 {
+		return uint8((int(v)*(255-a) + to*a + 127) / 255)
+	}
+	if y < pickerH/2 {
+		return color.RGBA{mix(c.R, 255-y, 255), mix(c.G, 255-y, 255), mix(c.B, 255-y, 255), 255}
+	}
+	a := y - pickerH/2
+	return color.RGBA{mix(c.R, a, 0), mix(c.G, a, 0), mix(c.B, a, 0), 255}
+}
+
+// solveSurface finds the picker coordinates closest to c on the formula for the generated
+// gradient, returning the coordinates and the remaining ΔE2000 distance to the formula
+func solveSurface(c color.RGBA) (x, y int, dE float64) {
+	h, s, v := rgbToHSB(c.R, c.G, c.B)
+	// 256 pixels cover each 60 degrees of hue
+	x = int(math.Round(h/60*255)) + int(h/60)
+	if x >= pickerW {
+		x -= pickerW
+	}
+	// the color lies either on the top half (full brightness) or the bottom half (full saturation)
+	top := int(math.Round(s * 255))
+	bottom := pickerH/2 + int(math.Round((1-v)*255))
+	target := rgbToLab(c)
+	topDE := deltaE2000(target, rgbToLab(surfaceColor(x, top)))
+	bottomDE := deltaE2000(target, rgbToLab(surfaceColor(x, bottom)))
+	if bottomDE < topDE {
+		top, topDE = bottom, bottomDE
+	}
+	// colors off the surface have no exact solution, so look for the closest one
+	if topDE > surfaceTolerance {
+		if sx, sy, sDE := nearestPoint(c, image.Rect(0, 0, pickerW, pickerH), surfaceColor); sDE < topDE {
+			return sx, sy, sDE
+		}
+	}
+	return x, top, topDE
+}
+
+// refinePickerPoint searches the picker image around x, y for the pixel closest to c,
+// returning its coordinates and the ΔE2000 distance to the image itself
+func refinePickerPoint(c color.RGBA, x, y int) (rx, ry int, dE float64) {
+	target := rgbToLab(c)
+	b := pickerImg.Bounds()
+	dE = math.Inf(1)
+	for py := y - surfaceRefine; py <= y+surfaceRefine; py++ {
+		for px := x - surfaceRefine; px <= x+surfaceRefine; px++ {
+			p := image.Pt(b.Min.X+px, b.Min.Y+py)
+			if !p.In(b) {
+				continue
+			}
+			if d := deltaE2000(target, rgbToLab(toRGBA(pickerImg.At(p.X, p.Y)))); d < dE {
+				rx, ry, dE = px, py, d
+			}
+		}
+	}
+	return
+}
+
+// pickerLab caches the L*a*b* value of every picker pixel for the solvers
+var pickerLab []labColor
+
+// pickerLabAt returns the cached L*a*b* color of the picker at x, y
+func pickerLabAt(x, y int) labColor {
+	if pickerLab == nil {
+		b := pickerImg.Bounds()
+		pickerLab = make([]labColor, pickerW*pickerH)
+		for py := 0; py < pickerH; py++ {
+			for px := 0; px < pickerW; px++ {
+				pickerLab[py*pickerW+px] = rgbToLab(toRGBA(pickerImg.At(b.Min.X+px, b.Min.Y+py)))
+			}
+		}
+	}
+	return pickerLab[y*pickerW+x]
+}